@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/integrii/flaggy"
+	"gitlab.com/soteapps/packages/v2021/sHelper"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// schemagen writes the Go structs of a message schema, e.g.
+//
+//	go run gitlab.com/soteapps/packages/v2021/cmd/schemagen -t FintransAdd -p packages -o packages/fintransadd.go \
+//		-f https://gitlab.com/soteapps/messages/-/raw/master/fin-trans-trip-add/request/fin-trans-trip-add.json
+func main() {
+	var (
+		fileName    string
+		packageName = "packages"
+		typeName    string
+		output      string
+		directory   string
+		isOffline   = false
+	)
+	flaggy.SetName("schemagen")
+	flaggy.SetDescription("Generates the Go request structs of a message JSON schema.")
+	flaggy.String(&fileName, "f", "file", "File name or URL of the message schema.")
+	flaggy.String(&typeName, "t", "type", "Name of the generated struct of the message.")
+	flaggy.String(&packageName, "p", "package", "Package of the generated file. (default: 'packages')")
+	flaggy.String(&output, "o", "output", "Generated file, the source is written to stdout when it isn't set.")
+	flaggy.String(&directory, "s", "schemaDir", "Local copies of the message schemas, see sHelper.SetSchemaDirectory.")
+	flaggy.Bool(&isOffline, "", "offline", "Only resolve the message schemas from the schema directory and the schema cache.")
+	flaggy.Parse()
+
+	if fileName == "" || typeName == "" {
+		flaggy.ShowHelpAndExit("file and type are required")
+	}
+	if directory != "" {
+		sHelper.SetSchemaDirectory(directory)
+	}
+	sHelper.SetSchemaOffline(isOffline)
+
+	source, soteErr := sHelper.GenerateStructs(fileName, packageName, typeName)
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+		os.Exit(1)
+	}
+	if output == "" {
+		os.Stdout.Write(source)
+	} else if err := ioutil.WriteFile(output, source, 0644); err != nil {
+		sLogger.Info(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/integrii/flaggy"
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// testtoken prints a token of the local test issuer, the services in test mode trust the key file of the repository when
+// SOTE_LOCAL_ISSUER is true, e.g.
+//
+//	TOKEN=$(go run gitlab.com/soteapps/packages/v2021/cmd/testtoken -c '{"cognito:groups": ["admin"]}')
+func main() {
+	var (
+		keyFile   = sAuthentication.LOCALKEYFILE
+		algorithm string
+		claims    string
+		isJWKS    = false
+	)
+	flaggy.SetName("testtoken")
+	flaggy.SetDescription("Signs a test token with the key of the local issuer.")
+	flaggy.String(&keyFile, "k", "key", "PEM key file of the issuer, created when it doesn't exist. (default: '"+keyFile+"')")
+	flaggy.String(&algorithm, "a", "algorithm", "Algorithm of a new key, RS256, PS256, ES256 or EdDSA. (default: 'RS256')")
+	flaggy.String(&claims, "c", "claims", "JSON object of the claims replacing the default claims, a null claim is removed.")
+	flaggy.Bool(&isJWKS, "", "jwks", "Print the public key set of the issuer instead of a token.")
+	flaggy.Parse()
+
+	issuer, soteErr := sAuthentication.LoadLocalIssuer(keyFile, algorithm)
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+		os.Exit(1)
+	}
+	if isJWKS {
+		fmt.Println(string(issuer.JWKS()))
+		return
+	}
+	tokenClaims := map[string]interface{}{}
+	if claims != "" {
+		if err := json.Unmarshal([]byte(claims), &tokenClaims); err != nil {
+			flaggy.ShowHelpAndExit("claims must be a JSON object: " + err.Error())
+		}
+	}
+	token, soteErr := issuer.Token(tokenClaims)
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+		os.Exit(1)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,19 @@
+--
+-- Audit trail of the tables declared with sHelper.AddAuditTable
+--
+CREATE TABLE IF NOT EXISTS sote.audittrail
+(
+    audittrail_id   bigserial                              NOT NULL
+        CONSTRAINT audittrail_pk
+            PRIMARY KEY,
+    audit_table     varchar(128)                           NOT NULL,
+    audit_action    varchar(10)                            NOT NULL,
+    before_image    jsonb,
+    after_image     jsonb,
+    aws_user_name   varchar(128)                           NOT NULL,
+    message_id      varchar(128),
+    audit_timestamp timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audittrail_table_timestamp_index
+    ON sote.audittrail (audit_table, audit_timestamp);
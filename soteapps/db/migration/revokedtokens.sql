@@ -0,0 +1,18 @@
+--
+-- Revoked tokens of sHelper.PostgresRevocations, a row blocks the token with the jti or the tokens of the username
+-- issued before issued_before
+--
+CREATE TABLE IF NOT EXISTS sote.revokedtokens
+(
+    revocation_key varchar(300)                           NOT NULL
+        CONSTRAINT revokedtokens_pk
+            PRIMARY KEY,
+    jti            varchar(256),
+    username       varchar(128),
+    issued_before  timestamp with time zone,
+    expires_at     timestamp with time zone,
+    revoked_at     timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS revokedtokens_expires_at_index
+    ON sote.revokedtokens (expires_at);
@@ -0,0 +1,10 @@
+--
+-- Soft delete columns of sote.tripfinancialtransactions, declared with sHelper.AddSoftDeleteTable by triptransaction
+--
+ALTER TABLE sote.tripfinancialtransactions
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS deleted_by varchar(128);
+
+CREATE INDEX IF NOT EXISTS tripfinancialtransactions_not_deleted_index
+    ON sote.tripfinancialtransactions (organizations_id)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,105 @@
+package sAuthentication
+
+import (
+	"crypto/ed25519"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const EDDSA = "EdDSA"
+
+var (
+	// DEFAULTALGORITHMS are the asymmetric algorithms accepted from a provider without Algorithms, "none" and the HMAC
+	// algorithms are always rejected because the public key of the provider would be the shared secret
+	DEFAULTALGORITHMS = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", EDDSA}
+	// the key type of the algorithm family
+	algorithmKeyTypes = map[string]jwa.KeyType{
+		"RS256": jwa.RSA, "RS384": jwa.RSA, "RS512": jwa.RSA,
+		"PS256": jwa.RSA, "PS384": jwa.RSA, "PS512": jwa.RSA,
+		"ES256": jwa.EC, "ES384": jwa.EC, "ES512": jwa.EC,
+		EDDSA: jwa.OKP,
+	}
+	SigningMethodEdDSA = &signingMethodEdDSA{}
+)
+
+// algorithmProvider is implemented by the identity providers restricting the algorithms of their tokens
+type algorithmProvider interface {
+	Algorithms() []string
+}
+
+func init() {
+	jwt.RegisterSigningMethod(EDDSA, func() jwt.SigningMethod { return SigningMethodEdDSA })
+}
+
+// allowedAlgorithms returns the algorithms of the provider, DEFAULTALGORITHMS when it doesn't implement Algorithms
+func allowedAlgorithms(provider IdentityProvider) []string {
+	if p, ok := provider.(algorithmProvider); ok {
+		if algorithms := p.Algorithms(); len(algorithms) > 0 {
+			return algorithms
+		}
+	}
+	return DEFAULTALGORITHMS
+}
+
+// checkAlgorithm returns 209500 when the algorithm of the token isn't allowed by the provider or doesn't match the key
+func checkAlgorithm(provider IdentityProvider, token *jwt.Token, key jwk.Key) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		alg     = token.Method.Alg()
+		allowed = false
+	)
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok || alg == "none" {
+		soteErr = sError.GetSError(209500, nil, sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg + " (" + alg + ")")
+		return
+	}
+	for _, algorithm := range allowedAlgorithms(provider) {
+		if algorithm == alg {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		soteErr = sError.GetSError(209500, nil, sError.EmptyMap)
+	} else if key != nil && (key.KeyType() != algorithmKeyTypes[alg] || (key.Algorithm() != "" && key.Algorithm() != alg)) {
+		soteErr = sError.GetSError(209500, nil, sError.EmptyMap) // e.g. a RS256 key used with PS256 or an EC token signed with a RSA key
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg + " (" + alg + ")")
+	}
+	return
+}
+
+// signingMethodEdDSA verifies the Ed25519 signatures (RFC 8037), jwt-go only implements the RSA, ECDSA and HMAC methods
+type signingMethodEdDSA struct{}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return EDDSA
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
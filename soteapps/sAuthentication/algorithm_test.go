@@ -0,0 +1,70 @@
+package sAuthentication
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestAlgorithmFamilies(t *testing.T) {
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	for _, algorithm := range []string{"RS256", "PS256", "ES256", EDDSA} {
+		issuer, soteErr := NewLocalIssuer(algorithm)
+		AssertEqual(t, soteErr.FmtErrMsg, "")
+		AssertEqual(t, issuer.Algorithm, algorithm)
+		SetIdentityProvider(ISSUERENVIRONMENT, issuer)
+		token, _ := issuer.Token(nil)
+		AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).FmtErrMsg, "")
+	}
+}
+
+func TestAlgorithmAllowed(t *testing.T) {
+	issuer, _ := NewLocalIssuer("PS256")
+	provider := &OIDCProvider{Issuer: LOCALISSUER, JWKSFile: writeJWKS(t, issuer), AllowedAlgorithms: []string{"RS256"}}
+	SetIdentityProvider(ISSUERENVIRONMENT, provider)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	token, _ := issuer.Token(nil)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 209500)
+	provider.AllowedAlgorithms = nil
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+}
+
+func TestAlgorithmKeyConfusion(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	SetIdentityProvider(ISSUERENVIRONMENT, &OIDCProvider{Issuer: LOCALISSUER, JWKSFile: writeJWKS(t, issuer)})
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	claims := jwt.MapClaims{"iss": LOCALISSUER}
+
+	// "none" is rejected
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	token.Header["kid"] = issuer.KeyId
+	rawToken, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, rawToken).ErrCode, 209500)
+
+	// HMAC signed with the public key of the issuer is rejected
+	publicKey, _ := x509.MarshalPKIXPublicKey(issuer.privateKey.Public())
+	token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = issuer.KeyId
+	rawToken, _ = token.SignedString(publicKey)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, rawToken).ErrCode, 209500)
+
+	// the algorithm must match the key of the kid
+	other, _ := NewLocalIssuer("ES256")
+	rawToken, _ = other.Token(nil)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, rawToken).ErrCode, 209500)
+	other, _ = NewLocalIssuer("PS256")
+	rawToken, _ = other.Token(nil)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, rawToken).ErrCode, 209500)
+}
+
+func writeJWKS(t *testing.T, issuer *LocalIssuer) string {
+	dir, _ := ioutil.TempDir("", "jwks")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	fileName := filepath.Join(dir, "jwks.json")
+	ioutil.WriteFile(fileName, issuer.JWKS(), 0600)
+	return fileName
+}
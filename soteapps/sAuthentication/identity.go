@@ -0,0 +1,76 @@
+package sAuthentication
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	ORGANIZATIONCLAIM = "custom:organizations-id"
+	CUSTOMCLAIMS      = "custom:" // prefix of the custom attributes of the user pool
+)
+
+// Identity is the requestor of a message as verified by the token, unlike the client supplied RequestHeaderSchema
+type Identity struct {
+	Subject        string
+	Username       string
+	Groups         []string
+	ClientId       string
+	OrganizationId int                    // 0 when the token has no organization claim
+	Custom         map[string]interface{} // custom:* claims
+	ExpiresAt      time.Time
+	Service        string // name of the calling service, Username is then the user the service acts for, see ValidateServiceToken
+}
+
+// ClaimIdentity returns the identity of the verified token
+func ClaimIdentity(claims jwt.MapClaims) (identity *Identity) {
+	identity = &Identity{Groups: ClaimRoles(claims), Custom: map[string]interface{}{}}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Username, _ = claims["username"].(string); identity.Username == "" {
+		identity.Username, _ = claims["cognito:username"].(string) // id tokens
+	}
+	if identity.ClientId, _ = claims["client_id"].(string); identity.ClientId == "" {
+		identity.ClientId, _ = claims["aud"].(string)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		identity.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	for name, claim := range claims {
+		if strings.HasPrefix(name, CUSTOMCLAIMS) {
+			identity.Custom[name] = claim
+		}
+	}
+	if claim, ok := claims[ORGANIZATIONCLAIM]; ok {
+		fmt.Sscan(fmt.Sprint(claim), &identity.OrganizationId)
+	}
+	return
+}
+
+// HasOrganization is true when the token has the organization claim, the Cognito access tokens have no custom claims
+func (i *Identity) HasOrganization() bool {
+	_, ok := i.Custom[ORGANIZATIONCLAIM]
+	return ok
+}
+
+// Match returns 208360 when the username or the organization of the request header aren't the ones of the token. The
+// organization is only matched when the token has the organization claim, and a service token without username claim
+// can't vouch for an aws-user-name
+func (i *Identity) Match(rh RequestHeaderSchema) (soteErr sError.SoteError) {
+	var invalid []string
+	if (i.Username != "" || i.Service != "") && rh.AwsUserName != i.Username {
+		invalid = append(invalid, "username")
+	}
+	if i.HasOrganization() && rh.OrganizationId != i.OrganizationId {
+		invalid = append(invalid, ORGANIZATIONCLAIM)
+	}
+	if len(invalid) > 0 {
+		soteErr = sError.GetSError(208360, sError.BuildParams([]string{fmt.Sprint(invalid)}), sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
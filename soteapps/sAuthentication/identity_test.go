@@ -0,0 +1,54 @@
+package sAuthentication
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentityValidate(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	expires := time.Now().Add(time.Minute).Unix()
+	token, _ := issuer.Token(map[string]interface{}{
+		"sub":             "user-id",
+		"client_id":       "client",
+		"exp":             expires,
+		ORGANIZATIONCLAIM: "10003",
+		"custom:region":   "eu",
+		GROUPSCLAIM:       []string{"admin", "driver"},
+	})
+	rh := RequestHeader{Header: RequestHeaderSchema{JsonWebToken: token, AwsUserName: "soteuser", OrganizationId: 10003}}
+	_, identity, soteErr := ValidateIdentity(rh, ISSUERENVIRONMENT, false)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, identity.Subject, "user-id")
+	AssertEqual(t, identity.Username, "soteuser")
+	AssertEqual(t, identity.ClientId, "client")
+	AssertEqual(t, identity.OrganizationId, 10003)
+	AssertEqual(t, identity.Custom["custom:region"], "eu")
+	AssertEqual(t, strings.Join(identity.Groups, ","), "admin,driver")
+	AssertEqual(t, identity.ExpiresAt.Unix(), expires)
+
+	rh.Header.AwsUserName = "other"
+	_, identity, soteErr = ValidateIdentity(rh, ISSUERENVIRONMENT, false)
+	AssertEqual(t, soteErr.FmtErrMsg, "208360: These claims are invalid: [username]")
+	AssertEqual(t, identity == nil, true)
+	rh.Header.AwsUserName, rh.Header.OrganizationId = "soteuser", 10004
+	_, soteErr = Validate(rh, ISSUERENVIRONMENT, false)
+	AssertEqual(t, soteErr.FmtErrMsg, "208360: These claims are invalid: [custom:organizations-id]")
+}
+
+func TestIdentityWithoutOrganization(t *testing.T) {
+	identity := ClaimIdentity(map[string]interface{}{"cognito:username": "soteuser", "aud": "client"})
+	AssertEqual(t, identity.Username, "soteuser")
+	AssertEqual(t, identity.ClientId, "client")
+	AssertEqual(t, identity.HasOrganization(), false)
+	// the access tokens of Cognito have no custom claims, the organization of the request header isn't matched
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003}).ErrCode, nil)
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "other", OrganizationId: 10003}).ErrCode, 208360)
+
+	identity = ClaimIdentity(map[string]interface{}{"cognito:username": "soteuser", ORGANIZATIONCLAIM: "invalid"})
+	AssertEqual(t, identity.HasOrganization(), true)
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003}).ErrCode, 208360)
+}
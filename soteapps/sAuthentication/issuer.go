@@ -0,0 +1,240 @@
+package sAuthentication
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lestrrat-go/jwx/jwk"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	LOCALISSUER  = "https://localhost/sote-local-issuer"
+	LOCALKEYID   = "sote-local-key"
+	LOCALKEYFILE = ".git/local-issuer.pem" // shared by the services in test mode and the scripts minting tokens
+	LOCALKEYENV  = "SOTE_LOCAL_ISSUER"     // set to true to trust the tokens of LOCALKEYFILE in test mode
+	LOCALEXPIRES = time.Hour
+)
+
+var (
+	localIssuers   = map[string]bool{} // environments checked for the key file
+	localIssuersMu sync.Mutex
+)
+
+// LocalIssuer signs test tokens with a generated RS256 or ES256 key. It is the identity provider of the environment in
+// test mode (see UseLocalIssuer), the tokens of the other issuers are verified by Next.
+type LocalIssuer struct {
+	Issuer     string
+	KeyId      string
+	Algorithm  string
+	Next       IdentityProvider
+	privateKey crypto.Signer
+	key        jwk.Key
+}
+
+// NewLocalIssuer generates the key of the issuer, algorithm is RS256 (default), PS256, ES256 or EdDSA
+func NewLocalIssuer(algorithm string) (issuer *LocalIssuer, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		privateKey crypto.Signer
+		err        error
+	)
+	switch algorithm {
+	case jwt.SigningMethodES256.Alg():
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EDDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, sError.GetSError(210599, nil, map[string]string{"ERROR": err.Error()})
+	}
+	return newLocalIssuer(privateKey, algorithm)
+}
+
+// LoadLocalIssuer reads the key of the issuer from the PEM file, the key is generated and saved when the file doesn't exist
+func LoadLocalIssuer(keyFile, algorithm string) (issuer *LocalIssuer, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		data []byte
+		err  error
+		key  interface{}
+	)
+	if data, err = ioutil.ReadFile(keyFile); os.IsNotExist(err) {
+		if issuer, soteErr = NewLocalIssuer(algorithm); soteErr.ErrCode == nil {
+			data, _ = x509.MarshalPKCS8PrivateKey(issuer.privateKey)
+			os.MkdirAll(filepath.Dir(keyFile), os.ModePerm)
+			if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}), 0600); err != nil {
+				soteErr = sError.GetSError(209010, sError.BuildParams([]string{keyFile, err.Error()}), sError.EmptyMap)
+			}
+		}
+		return
+	} else if err != nil {
+		return nil, sError.GetSError(209010, sError.BuildParams([]string{keyFile, err.Error()}), sError.EmptyMap)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, sError.GetSError(207110, sError.BuildParams([]string{keyFile}), sError.EmptyMap)
+	}
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		return nil, sError.GetSError(207110, sError.BuildParams([]string{keyFile}), sError.EmptyMap)
+	}
+	return newLocalIssuer(key.(crypto.Signer), algorithm)
+}
+
+// newLocalIssuer selects the algorithm of the key, a RSA key signs with PS256 or RS256
+func newLocalIssuer(privateKey crypto.Signer, algorithm string) (issuer *LocalIssuer, soteErr sError.SoteError) {
+	issuer = &LocalIssuer{Issuer: LOCALISSUER, KeyId: LOCALKEYID, privateKey: privateKey, Algorithm: jwt.SigningMethodRS256.Alg()}
+	switch privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		issuer.Algorithm = jwt.SigningMethodES256.Alg()
+	case ed25519.PrivateKey:
+		issuer.Algorithm = EDDSA
+	default:
+		if algorithm == jwt.SigningMethodPS256.Alg() {
+			issuer.Algorithm = algorithm
+		}
+	}
+	issuer.key, _ = jwk.New(privateKey.Public())
+	issuer.key.Set(jwk.KeyIDKey, issuer.KeyId)
+	issuer.key.Set(jwk.AlgorithmKey, issuer.Algorithm)
+	return
+}
+
+// UseLocalIssuer makes the issuer the identity provider of the environment, the other tokens are verified by the current
+// provider when Next isn't set
+func UseLocalIssuer(tEnvironment string, issuer *LocalIssuer) {
+	sLogger.DebugMethod()
+	if issuer.Next == nil {
+		// the local issuers of the environment are replaced
+		next := identityProvider(tEnvironment)
+		replaced := map[*LocalIssuer]bool{issuer: true}
+		for current, ok := next.(*LocalIssuer); ok; current, ok = next.(*LocalIssuer) {
+			if replaced[current] {
+				next = nil
+				break
+			}
+			replaced[current] = true
+			next = current.Next
+		}
+		issuer.Next = next
+	}
+	SetIdentityProvider(tEnvironment, issuer)
+}
+
+// useLocalKeyFile trusts the tokens minted with the local key file in test mode (see cmd/testtoken), only when the
+// LOCALKEYENV environment variable is true
+func useLocalKeyFile(tEnvironment string) {
+	if os.Getenv(LOCALKEYENV) != "true" {
+		return
+	}
+	localIssuersMu.Lock()
+	defer localIssuersMu.Unlock()
+	if localIssuers[tEnvironment] {
+		return
+	}
+	localIssuers[tEnvironment] = true
+	if _, err := os.Stat(LOCALKEYFILE); err == nil {
+		if issuer, soteErr := LoadLocalIssuer(LOCALKEYFILE, ""); soteErr.ErrCode == nil {
+			UseLocalIssuer(tEnvironment, issuer)
+		} else {
+			sLogger.Info(soteErr.FmtErrMsg)
+		}
+	}
+}
+
+// Token signs a token of the issuer, the claims replace the default claims of a Cognito access token
+func (i *LocalIssuer) Token(claims map[string]interface{}) (rawToken string, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	now := time.Now()
+	tokenClaims := jwt.MapClaims{
+		"iss":       i.Issuer,
+		"sub":       "soteuser",
+		"username":  "soteuser",
+		"token_use": "access",
+		"scope":     "aws.cognito.signin.user.admin",
+		"iat":       now.Unix(),
+		"exp":       now.Add(LOCALEXPIRES).Unix(),
+	}
+	for name, claim := range claims {
+		if claim == nil {
+			delete(tokenClaims, name)
+		} else {
+			tokenClaims[name] = claim
+		}
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(i.Algorithm), tokenClaims)
+	token.Header["kid"] = i.KeyId
+	var err error
+	if rawToken, err = token.SignedString(i.privateKey); err != nil {
+		soteErr = sError.GetSError(210599, nil, map[string]string{"ERROR": err.Error()})
+	}
+	return
+}
+
+// JWKS returns the public key set of the issuer
+func (i *LocalIssuer) JWKS() []byte {
+	keySet := jwk.NewSet()
+	keySet.Add(i.key)
+	data, _ := json.Marshal(keySet)
+	return data
+}
+
+// ServeHTTP serves the public key set, e.g. for the OIDCProvider of another process
+func (i *LocalIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(i.JWKS())
+}
+
+// IssuerKey returns the key of the issuer only for the tokens of the issuer, the other tokens are verified by the keys of Next
+func (i *LocalIssuer) IssuerKey(issuer, kid string) (jwk.Key, sError.SoteError) {
+	if issuer == i.Issuer && kid == i.KeyId {
+		return i.key, sError.SoteError{}
+	} else if issuer != i.Issuer && i.Next != nil {
+		return providerKey(i.Next, issuer, kid)
+	}
+	return nil, sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
+}
+
+// Key returns the key of the kid, ValidToken uses IssuerKey so that the key only verifies the tokens of the issuer
+func (i *LocalIssuer) Key(kid string) (jwk.Key, sError.SoteError) {
+	if kid == i.KeyId {
+		return i.key, sError.SoteError{}
+	} else if i.Next != nil {
+		return i.Next.Key(kid)
+	}
+	return nil, sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
+}
+
+// Algorithms allows the algorithm of the issuer and the algorithms of Next
+func (i *LocalIssuer) Algorithms() []string {
+	if i.Next == nil {
+		return []string{i.Algorithm}
+	}
+	return append([]string{i.Algorithm}, allowedAlgorithms(i.Next)...)
+}
+
+func (i *LocalIssuer) ValidateClaims(claims jwt.MapClaims) sError.SoteError {
+	if claims["iss"] == i.Issuer {
+		return sError.SoteError{}
+	} else if i.Next != nil {
+		return i.Next.ValidateClaims(claims)
+	}
+	return sError.GetSError(208300, nil, sError.EmptyMap)
+}
@@ -0,0 +1,121 @@
+package sAuthentication
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	ISSUERENVIRONMENT = "issuer"
+)
+
+func TestIssuerToken(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "ES256"} {
+		issuer, soteErr := NewLocalIssuer(algorithm)
+		AssertEqual(t, soteErr.ErrCode, nil)
+		AssertEqual(t, issuer.Algorithm, algorithm)
+		UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+
+		token, _ := issuer.Token(map[string]interface{}{GROUPSCLAIM: []string{"admin"}})
+		claims, soteErr := ValidTokenClaims(ISSUERENVIRONMENT, token)
+		AssertEqual(t, soteErr.FmtErrMsg, "")
+		AssertEqual(t, ClaimRoles(claims)[0], "admin")
+		AssertEqual(t, claims["token_use"], "access")
+
+		token, _ = issuer.Token(map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()})
+		AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208350)
+	}
+	SetIdentityProvider(ISSUERENVIRONMENT, nil)
+}
+
+func TestIssuerNext(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	other, _ := NewLocalIssuer("")
+	other.Issuer, other.KeyId = "https://other.example.com", "other-key"
+	issuer.Next = other
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+
+	token, _ := other.Token(nil)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+	// the key of a local issuer only verifies the tokens of the issuer
+	token, _ = issuer.Token(map[string]interface{}{"iss": "https://unknown.example.com"})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 209521)
+	token, _ = issuer.Token(map[string]interface{}{"iss": other.Issuer})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 209521)
+	token, _ = other.Token(map[string]interface{}{"iss": issuer.Issuer})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 209521)
+
+	// a new local issuer replaces the local issuers of the environment
+	replacement, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, replacement)
+	AssertEqual(t, replacement.Next, nil)
+	SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	replacement.Next = nil
+	UseLocalIssuer(ISSUERENVIRONMENT, replacement)
+	UseLocalIssuer(ISSUERENVIRONMENT, other)
+	_, ok := other.Next.(CognitoProvider)
+	AssertEqual(t, ok, true)
+}
+
+func TestIssuerLoad(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "issuer")
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, ".git", "local-issuer.pem")
+	issuer, soteErr := LoadLocalIssuer(keyFile, "ES256")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	loaded, soteErr := LoadLocalIssuer(keyFile, "")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, loaded.Algorithm, "ES256")
+
+	UseLocalIssuer(ISSUERENVIRONMENT, loaded)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	token, _ := issuer.Token(nil)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+
+	ioutil.WriteFile(keyFile, []byte("KEY"), 0600)
+	_, soteErr = LoadLocalIssuer(keyFile, "")
+	AssertEqual(t, soteErr.ErrCode, 207110)
+}
+
+func TestIssuerJWKS(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	server := httptest.NewServer(issuer)
+	defer server.Close()
+	SetIdentityProvider(ISSUERENVIRONMENT, &OIDCProvider{Issuer: LOCALISSUER, JWKSURL: server.URL})
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+
+	token, _ := issuer.Token(jwt.MapClaims{"sub": nil})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+}
+
+func TestIssuerLocalKeyFileOptIn(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "issuer")
+	defer os.RemoveAll(dir)
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(dir)
+	issuer, soteErr := LoadLocalIssuer(LOCALKEYFILE, "")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	token, _ := issuer.Token(nil)
+	defer SetIdentityProvider("opt-in", nil)
+
+	// the key file of the repository isn't trusted by default
+	os.Unsetenv(LOCALKEYENV)
+	_, soteErr = TokenIdentity(token, "opt-in", true)
+	AssertEqual(t, soteErr.ErrCode != nil, true)
+
+	os.Setenv(LOCALKEYENV, "true")
+	defer os.Unsetenv(LOCALKEYENV)
+	_, soteErr = TokenIdentity(token, "opt-in", false)
+	AssertEqual(t, soteErr.ErrCode != nil, true) // only in test mode
+	identity, soteErr := TokenIdentity(token, "opt-in", true)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, identity.Username, "soteuser")
+}
@@ -0,0 +1,187 @@
+package sAuthentication
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"gitlab.com/soteapps/packages/v2021/sConfigParams"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	JWKSPATH    = "/.well-known/jwks.json"
+	JWKSTTL     = time.Hour        // the keys are fetched again after the TTL
+	JWKSREFRESH = 30 * time.Minute // the keys are refreshed in the background after
+	JWKSREFETCH = time.Minute      // minimum time between two fetches of the keys, e.g. for an unknown kid
+	JWKSTIMEOUT = 10 * time.Second
+)
+
+var (
+	jwks = newJwksCache()
+	// hits, misses, refreshes, refetches and errors of the JWKS cache, published on /debug/vars
+	jwksMetrics = expvar.NewMap("jwks")
+)
+
+type jwksEntry struct {
+	keySet     jwk.Set
+	fetched    time.Time
+	refetched  time.Time
+	retryAt    time.Time // the IdP is down, the keys are used until the next retry
+	refreshing bool
+}
+
+// jwksCache holds the JSON web key set of each issuer, the keys survive a brief outage of the IdP
+type jwksCache struct {
+	ttl     time.Duration
+	refresh time.Duration
+	refetch time.Duration
+	mu      sync.Mutex
+	entries map[string]*jwksEntry
+	urls    map[string]string // issuer -> JWKS URL, the well-known JWKS of the issuer when it isn't set
+	issuers map[string]string // environment -> issuer of its Cognito user pool
+	fetch   func(ctx context.Context, url string, options ...jwk.FetchOption) (jwk.Set, error)
+	resolve func(tEnvironment string) (string, sError.SoteError)
+	now     func() time.Time
+}
+
+func newJwksCache() *jwksCache {
+	return &jwksCache{
+		ttl:     JWKSTTL,
+		refresh: JWKSREFRESH,
+		refetch: JWKSREFETCH,
+		entries: map[string]*jwksEntry{},
+		urls:    map[string]string{},
+		issuers: map[string]string{},
+		fetch:   jwk.Fetch,
+		resolve: resolveCognitoIssuer,
+		now:     time.Now,
+	}
+}
+
+// SetJWKSCache changes how long the keys of an issuer are cached, refreshed in the background and refetched for an unknown kid
+func SetJWKSCache(ttl, refresh, refetch time.Duration) {
+	sLogger.DebugMethod()
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+	jwks.ttl, jwks.refresh, jwks.refetch = ttl, refresh, refetch
+}
+
+func (c *jwksCache) setURL(issuer, url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.urls[issuer] = url
+}
+
+// issuer returns the issuer of the Cognito user pool of the environment, the region and user pool id are read once
+func (c *jwksCache) issuer(tEnvironment string) (issuer string, soteErr sError.SoteError) {
+	c.mu.Lock()
+	issuer, ok := c.issuers[tEnvironment]
+	c.mu.Unlock()
+	if !ok {
+		if issuer, soteErr = c.resolve(tEnvironment); soteErr.ErrCode == nil {
+			c.mu.Lock()
+			c.issuers[tEnvironment] = issuer
+			c.mu.Unlock()
+		}
+	}
+	return
+}
+
+func resolveCognitoIssuer(tEnvironment string) (issuer string, soteErr sError.SoteError) {
+	var region, userPoolId string
+	if region, soteErr = sConfigParams.GetRegion(); soteErr.ErrCode == nil {
+		if userPoolId, soteErr = sConfigParams.GetUserPoolId(tEnvironment); soteErr.ErrCode == nil {
+			issuer = "https://cognito-idp." + region + ".amazonaws.com/" + userPoolId
+		}
+	}
+	return
+}
+
+// keySet returns the cached keys of the issuer, the keys are fetched when they are missing or expired
+func (c *jwksCache) keySet(issuer string) (jwk.Set, error) {
+	c.mu.Lock()
+	now := c.now()
+	if entry := c.entries[issuer]; entry != nil && (now.Sub(entry.fetched) < c.ttl || now.Before(entry.retryAt)) {
+		if now.Sub(entry.fetched) >= c.refresh && !now.Before(entry.retryAt) && !entry.refreshing {
+			entry.refreshing = true
+			jwksMetrics.Add("refreshes", 1)
+			go c.load(issuer)
+		}
+		keySet := entry.keySet // load replaces the keys of the entry
+		c.mu.Unlock()
+		return keySet, nil
+	}
+	c.mu.Unlock()
+	jwksMetrics.Add("misses", 1)
+	return c.load(issuer)
+}
+
+// lookup returns the key of the kid, the keys are refetched once per refetch interval when the kid is unknown (key rotation)
+func (c *jwksCache) lookup(issuer, kid string) (key jwk.Key, err error) {
+	var (
+		keySet jwk.Set
+		ok     bool
+	)
+	if keySet, err = c.keySet(issuer); err != nil {
+		return
+	}
+	if key, ok = keySet.LookupKeyID(kid); ok {
+		jwksMetrics.Add("hits", 1)
+		return
+	}
+	if c.refetchable(issuer) {
+		jwksMetrics.Add("refetches", 1)
+		if keySet, err = c.load(issuer); err == nil {
+			key, _ = keySet.LookupKeyID(kid)
+		}
+	}
+	return
+}
+
+func (c *jwksCache) refetchable(issuer string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entry := c.entries[issuer]
+	if entry == nil || now.Sub(entry.refetched) < c.refetch || now.Sub(entry.fetched) < c.refetch {
+		return false
+	}
+	entry.refetched = now
+	return true
+}
+
+// load fetches the keys of the issuer, the cached keys are kept when the IdP is down
+func (c *jwksCache) load(issuer string) (jwk.Set, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), JWKSTIMEOUT)
+	defer cancel()
+	c.mu.Lock()
+	url, ok := c.urls[issuer]
+	c.mu.Unlock()
+	if !ok {
+		url = issuer + JWKSPATH
+	}
+	keySet, err := c.fetch(ctx, url)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[issuer]
+	if err != nil {
+		jwksMetrics.Add("errors", 1)
+		if entry == nil {
+			return nil, err
+		}
+		sLogger.Info("The JSON web keys couldn't be fetched, using the cached keys") // the URL should not be output to logs
+		entry.retryAt = c.now().Add(c.refetch)
+		entry.refreshing = false
+		return entry.keySet, nil
+	}
+	if entry == nil {
+		entry = &jwksEntry{}
+		c.entries[issuer] = entry
+	}
+	entry.keySet, entry.fetched, entry.retryAt, entry.refreshing = keySet, c.now(), time.Time{}, false
+	return keySet, nil
+}
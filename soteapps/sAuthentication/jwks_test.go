@@ -0,0 +1,175 @@
+package sAuthentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+const (
+	ISSUER = "https://idp.example.com"
+)
+
+func newTestKeySet(tPtr *testing.T, kids ...string) jwk.Set {
+	keySet := jwk.NewSet()
+	for _, kid := range kids {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			tPtr.Fatal(err)
+		}
+		key, _ := jwk.New(privateKey.Public())
+		key.Set(jwk.KeyIDKey, kid)
+		keySet.Add(key)
+	}
+	return keySet
+}
+
+func newTestJwksCache(tPtr *testing.T, fetches *int, keySet *jwk.Set, err *error) (*jwksCache, *time.Time) {
+	now := time.Now()
+	c := newJwksCache()
+	c.now = func() time.Time { return now }
+	c.fetch = func(ctx context.Context, url string, options ...jwk.FetchOption) (jwk.Set, error) {
+		if url != ISSUER+JWKSPATH {
+			tPtr.Errorf("fetch failed: Expected url to be %v: %v", ISSUER+JWKSPATH, url)
+		}
+		*fetches++
+		return *keySet, *err
+	}
+	return c, &now
+}
+
+func TestJwksCache(tPtr *testing.T) {
+	var (
+		fetches int
+		err     error
+		key     jwk.Key
+	)
+	keySet := newTestKeySet(tPtr, "kid1")
+	c, now := newTestJwksCache(tPtr, &fetches, &keySet, &err)
+
+	for i := 0; i < 3; i++ {
+		if key, _ = c.lookup(ISSUER, "kid1"); key == nil {
+			tPtr.Errorf("lookup failed: Expected kid1 to be found")
+		}
+	}
+	if fetches != 1 {
+		tPtr.Errorf("lookup failed: Expected fetches to be 1: %v", fetches)
+	}
+
+	// the IdP is down, the cached keys are used
+	*now = now.Add(JWKSTTL)
+	err = errors.New("connection refused")
+	if key, err = c.lookup(ISSUER, "kid1"); key == nil || err != nil {
+		tPtr.Errorf("lookup failed: Expected kid1 to be found: %v", err)
+	}
+	if fetches != 2 {
+		tPtr.Errorf("lookup failed: Expected fetches to be 2: %v", fetches)
+	}
+	c.lookup(ISSUER, "kid1")
+	if fetches != 2 {
+		tPtr.Errorf("lookup failed: Expected no fetch until the retry: %v", fetches)
+	}
+}
+
+func TestJwksCacheRotation(tPtr *testing.T) {
+	var (
+		fetches int
+		err     error
+		key     jwk.Key
+	)
+	keySet := newTestKeySet(tPtr, "kid1")
+	c, now := newTestJwksCache(tPtr, &fetches, &keySet, &err)
+	c.lookup(ISSUER, "kid1")
+
+	// the unknown kid is refetched once per refetch interval
+	keySet = newTestKeySet(tPtr, "kid1", "kid2")
+	if key, _ = c.lookup(ISSUER, "kid2"); key != nil || fetches != 1 {
+		tPtr.Errorf("lookup failed: Expected kid2 not to be refetched: %v", fetches)
+	}
+	*now = now.Add(JWKSREFETCH)
+	if key, _ = c.lookup(ISSUER, "kid2"); key == nil || fetches != 2 {
+		tPtr.Errorf("lookup failed: Expected kid2 to be refetched: %v", fetches)
+	}
+	if key, _ = c.lookup(ISSUER, "kid3"); key != nil || fetches != 2 {
+		tPtr.Errorf("lookup failed: Expected kid3 not to be refetched: %v", fetches)
+	}
+}
+
+func TestJwksCacheRefresh(tPtr *testing.T) {
+	var (
+		fetches int
+		err     error
+	)
+	keySet := newTestKeySet(tPtr, "kid1")
+	c, now := newTestJwksCache(tPtr, &fetches, &keySet, &err)
+	c.fetch = func(ctx context.Context, url string, options ...jwk.FetchOption) (jwk.Set, error) {
+		fetches++
+		return keySet, err
+	}
+	c.lookup(ISSUER, "kid1")
+	*now = now.Add(JWKSREFRESH)
+	if key, _ := c.lookup(ISSUER, "kid1"); key == nil {
+		tPtr.Errorf("lookup failed: Expected kid1 to be found while the keys are refreshed")
+	}
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		refreshing := c.entries[ISSUER].refreshing
+		c.mu.Unlock()
+		if !refreshing {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.entries[ISSUER].fetched.Equal(*now) {
+		tPtr.Errorf("lookup failed: Expected the keys to be refreshed in the background")
+	}
+}
+
+func TestJwksCacheError(tPtr *testing.T) {
+	var (
+		fetches int
+		keySet  jwk.Set
+		err     = errors.New("connection refused")
+	)
+	c, _ := newTestJwksCache(tPtr, &fetches, &keySet, &err)
+	if _, err := c.lookup(ISSUER, "kid1"); err == nil {
+		tPtr.Errorf("lookup failed: Expected an error without cached keys")
+	}
+}
+
+func TestJwksCacheIssuer(tPtr *testing.T) {
+	var (
+		fetches, resolves int
+		keySet            jwk.Set
+		err               error
+	)
+	c, _ := newTestJwksCache(tPtr, &fetches, &keySet, &err)
+	c.resolve = func(tEnvironment string) (string, sError.SoteError) {
+		resolves++
+		if tEnvironment == "missing" {
+			return "", sError.GetSError(109999, sError.BuildParams([]string{"USER_POOL_ID"}), sError.EmptyMap)
+		}
+		return ISSUER, sError.SoteError{}
+	}
+	for i := 0; i < 3; i++ {
+		if issuer, soteErr := c.issuer("staging"); issuer != ISSUER || soteErr.ErrCode != nil {
+			tPtr.Errorf("issuer failed: Expected %v: %v %v", ISSUER, issuer, soteErr.FmtErrMsg)
+		}
+	}
+	if resolves != 1 {
+		tPtr.Errorf("issuer failed: Expected the issuer to be resolved once: %v", resolves)
+	}
+	// the errors are not cached, the parameters are read again on the next token
+	c.issuer("missing")
+	if _, soteErr := c.issuer("missing"); soteErr.ErrCode != 109999 || resolves != 3 {
+		tPtr.Errorf("issuer failed: Expected 109999 and the issuer to be resolved again: %v %v", soteErr.FmtErrMsg, resolves)
+	}
+}
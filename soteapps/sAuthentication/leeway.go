@@ -0,0 +1,107 @@
+package sAuthentication
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const DEFAULTLEEWAY = 30 * time.Second
+
+var (
+	leeway = DEFAULTLEEWAY // clock skew between the issuer and the services
+	// the claims are checked by validateTimes and the providers, the parser only verifies the signature
+	tokenParser = &jwt.Parser{SkipClaimsValidation: true}
+)
+
+// SetLeeway sets the clock skew tolerated for the exp, nbf and iat claims (default: DEFAULTLEEWAY)
+func SetLeeway(clockSkew time.Duration) {
+	sLogger.DebugMethod()
+	leeway = clockSkew
+}
+
+// parseToken verifies the signature of the token, the errors of the key function are set by the key function
+func parseToken(rawToken string, keyFunc jwt.Keyfunc) (token *jwt.Token, soteErr sError.SoteError) {
+	if len(strings.Split(rawToken, ".")) != 3 {
+		return nil, sError.GetSError(208356, nil, sError.EmptyMap)
+	}
+	token, err := tokenParser.Parse(rawToken, keyFunc)
+	if err == nil && !token.Valid {
+		soteErr = sError.GetSError(208355, nil, sError.EmptyMap)
+	} else if err != nil {
+		soteErr = sError.GetSError(208355, nil, map[string]string{"ERROR": err.Error()})
+		if validationErr, ok := err.(*jwt.ValidationError); ok && validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+			soteErr = sError.GetSError(208357, nil, sError.EmptyMap)
+		}
+	}
+	return
+}
+
+// validateTimes checks the exp (required), nbf and iat claims with the leeway
+func validateTimes(claims jwt.MapClaims) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		now     = time.Now()
+		details = func(name string, claim time.Time) map[string]string {
+			return map[string]string{name: claim.UTC().Format(time.RFC3339), "now": now.UTC().Format(time.RFC3339), "leeway": leeway.String()}
+		}
+	)
+	exp, hasExp, soteErr := timeClaim(claims, "exp")
+	if soteErr.ErrCode != nil {
+		return
+	}
+	nbf, hasNbf, soteErr := timeClaim(claims, "nbf")
+	if soteErr.ErrCode != nil {
+		return
+	}
+	iat, hasIat, soteErr := timeClaim(claims, "iat")
+	switch {
+	case soteErr.ErrCode != nil:
+	case !hasExp:
+		soteErr = sError.GetSError(208370, nil, map[string]string{"claim": "exp"})
+	case now.After(exp.Add(leeway)):
+		soteErr = sError.GetSError(208350, nil, details("exp", exp))
+	case hasNbf && now.Add(leeway).Before(nbf):
+		soteErr = sError.GetSError(208351, nil, details("nbf", nbf))
+	case hasIat && now.Add(leeway).Before(iat):
+		soteErr = sError.GetSError(208352, nil, details("iat", iat))
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
+
+// timeClaim reads a NumericDate claim, 208360 when the claim isn't a number
+func timeClaim(claims jwt.MapClaims, name string) (claimTime time.Time, ok bool, soteErr sError.SoteError) {
+	var seconds float64
+	switch claim := claims[name].(type) {
+	case nil:
+		return
+	case float64:
+		seconds = claim
+	case json.Number:
+		seconds, _ = claim.Float64()
+	default:
+		soteErr = sError.GetSError(208360, sError.BuildParams([]string{"[" + name + "]"}), sError.EmptyMap)
+		return
+	}
+	return time.Unix(int64(seconds), 0), true, soteErr
+}
+
+// validateAudience returns 208360 when the aud claim doesn't contain the audience
+func validateAudience(claims jwt.MapClaims, audience string) (soteErr sError.SoteError) {
+	if !claimContains(claims["aud"], audience) {
+		soteErr = sError.GetSError(208360, sError.BuildParams([]string{"[aud]"}), map[string]string{"aud": stringClaim(claims["aud"]), "expected": audience})
+	}
+	return
+}
+
+func stringClaim(claim interface{}) string {
+	data, _ := json.Marshal(claim)
+	return string(data)
+}
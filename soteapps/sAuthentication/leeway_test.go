@@ -0,0 +1,92 @@
+package sAuthentication
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeewayTimes(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	defer SetLeeway(DEFAULTLEEWAY)
+	SetLeeway(time.Minute)
+	now := time.Now()
+	// the clock of the issuer is 30 seconds (within the leeway) or 2 minutes (beyond the leeway) ahead or behind
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		code   interface{}
+	}{
+		{"exp within the leeway", map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()}, nil},
+		{"exp beyond the leeway", map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()}, 208350},
+		{"nbf within the leeway", map[string]interface{}{"nbf": now.Add(30 * time.Second).Unix()}, nil},
+		{"nbf beyond the leeway", map[string]interface{}{"nbf": now.Add(2 * time.Minute).Unix()}, 208351},
+		{"iat within the leeway", map[string]interface{}{"iat": now.Add(30 * time.Second).Unix()}, nil},
+		{"iat beyond the leeway", map[string]interface{}{"iat": now.Add(2 * time.Minute).Unix()}, 208352},
+		{"exp missing", map[string]interface{}{"exp": nil}, 208370},
+		{"exp not a number", map[string]interface{}{"exp": "tomorrow"}, 208360},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, _ := issuer.Token(test.claims)
+			AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, test.code)
+		})
+	}
+
+	SetLeeway(0)
+	token, _ := issuer.Token(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208350)
+}
+
+func TestLeewayFailureCodes(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	token, _ := issuer.Token(map[string]interface{}{"sub": "user"})
+	segments := strings.Split(token, ".")
+	tampered := segments[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"`+LOCALISSUER+`","sub":"admin","exp":4102444800}`)) + "." + segments[2]
+	tests := []struct {
+		name  string
+		token string
+		code  interface{}
+	}{
+		{"valid", token, nil},
+		{"payload changed after the signature", tampered, 208357},
+		{"signature cut", token[:len(token)-4], 208357},
+		{"segments aren't JSON", "a.b.c", 208355},
+		{"two segments", "a.b", 208356},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			AssertEqual(t, ValidToken(ISSUERENVIRONMENT, test.token).ErrCode, test.code)
+		})
+	}
+}
+
+func TestLeewayErrorDetails(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	now := time.Now()
+	tests := []struct {
+		claim string
+		value time.Time
+		code  int
+	}{
+		{"exp", now.Add(-time.Hour), 208350},
+		{"nbf", now.Add(time.Hour), 208351},
+		{"iat", now.Add(time.Hour), 208352},
+	}
+	for _, test := range tests {
+		t.Run(test.claim, func(t *testing.T) {
+			token, _ := issuer.Token(map[string]interface{}{test.claim: test.value.Unix()})
+			soteErr := ValidToken(ISSUERENVIRONMENT, token)
+			AssertEqual(t, soteErr.ErrCode, test.code)
+			AssertEqual(t, soteErr.ErrorDetails[test.claim], test.value.UTC().Format(time.RFC3339))
+			AssertEqual(t, soteErr.ErrorDetails["leeway"], DEFAULTLEEWAY.String())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package sAuthentication
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const GROUPSCLAIM = "cognito:groups"
+
+var (
+	policies   = map[string][]string{} // subject (NATS wildcards * and >) -> allowed roles
+	policiesMu sync.RWMutex
+)
+
+// AddPolicy allows the roles to send the messages of the subject, the subjects without a policy are allowed to every role.
+// The subject may use the NATS wildcards, e.g. bsl.fin-trans.> or bsl.*.trip.add
+func AddPolicy(subject string, roles ...string) {
+	sLogger.DebugMethod()
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies[subject] = roles
+}
+
+func RemovePolicy(subject string) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	delete(policies, subject)
+}
+
+// HasPolicy reports if the messages of the subject are restricted to roles
+func HasPolicy(subject string) bool {
+	_, ok := allowedRoles(subject)
+	return ok
+}
+
+// Authorize returns 100100 when none of the roles may send the messages of the subject
+func Authorize(subject string, roles []string) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	if allowed, ok := allowedRoles(subject); ok {
+		for _, role := range roles {
+			if _, ok = allowed[role]; ok {
+				return
+			}
+		}
+		soteErr = sError.GetSError(100100, sError.BuildParams([]string{"[" + strings.Join(roles, ", ") + "]", subject}), sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
+
+// allowedRoles returns the roles of the most specific policy matching the subject: an exact subject comes before a pattern
+// with *, and a pattern with * before a pattern with >, then the pattern with more literal tokens. The roles of equally
+// specific patterns are merged, a broad policy never widens a stricter one.
+func allowedRoles(subject string) (allowed map[string]struct{}, ok bool) {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	var best [2]int
+	for pattern, roles := range policies {
+		if !MatchSubject(pattern, subject) {
+			continue
+		}
+		rank := specificity(pattern)
+		if !ok || rank[0] > best[0] || (rank[0] == best[0] && rank[1] > best[1]) {
+			allowed, best, ok = map[string]struct{}{}, rank, true
+		} else if rank != best {
+			continue
+		}
+		for _, role := range roles {
+			allowed[role] = struct{}{}
+		}
+	}
+	return
+}
+
+// specificity ranks a pattern: 2 for an exact subject, 1 with *, 0 with >, and its number of literal tokens
+func specificity(pattern string) (rank [2]int) {
+	rank[0] = 2
+	for _, token := range strings.Split(pattern, ".") {
+		switch token {
+		case ">":
+			rank[0] = 0
+		case "*":
+			if rank[0] > 1 {
+				rank[0] = 1
+			}
+		default:
+			rank[1]++
+		}
+	}
+	return
+}
+
+// MatchSubject reports if the subject matches the pattern, the pattern may use the NATS wildcards * and >
+func MatchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		} else if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// ValidateRoles returns the roles of the request header from the verified token
+func ValidateRoles(rh RequestHeaderSchema, tEnvironment string, isTestMode bool) (roles []string, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var identity *Identity
+	if identity, soteErr = TokenIdentity(rh.JsonWebToken, tEnvironment, isTestMode); soteErr.ErrCode == nil {
+		roles = identity.Groups
+	}
+	return
+}
+
+// ClaimRoles returns the groups of the verified token, the client supplied role-list isn't trusted
+func ClaimRoles(claims jwt.MapClaims) (roles []string) {
+	switch groups := claims[GROUPSCLAIM].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if role, ok := group.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	case []string:
+		roles = groups
+	case string:
+		roles = strings.Fields(groups)
+	}
+	return
+}
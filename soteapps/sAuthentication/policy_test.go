@@ -0,0 +1,68 @@
+package sAuthentication
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/soteapps/packages/v2021/sConfigParams"
+)
+
+func TestPolicyAuthorize(t *testing.T) {
+	AddPolicy("bsl.fin-trans.>", "admin")
+	AddPolicy("bsl.*.trip.remove", "manager")
+	defer RemovePolicy("bsl.fin-trans.>")
+	defer RemovePolicy("bsl.*.trip.remove")
+
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.add", []string{"admin"}).ErrCode, nil)
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.remove", []string{"manager"}).ErrCode, nil)
+	AssertEqual(t, Authorize("bsl.notification.add", nil).ErrCode, nil)
+	soteErr := Authorize("bsl.fin-trans.trip.add", []string{"manager", "driver"})
+	AssertEqual(t, soteErr.ErrCode, 100100)
+	AssertEqual(t, strings.Contains(soteErr.FmtErrMsg, "Your roles [manager, driver] are not authorized to bsl.fin-trans.trip.add"), true)
+}
+
+func TestPolicySpecific(t *testing.T) {
+	AddPolicy("bsl.>", "user", "admin")
+	AddPolicy("bsl.*.trip.>", "manager")
+	AddPolicy("bsl.fin-trans.trip.remove", "admin")
+	defer RemovePolicy("bsl.>")
+	defer RemovePolicy("bsl.*.trip.>")
+	defer RemovePolicy("bsl.fin-trans.trip.remove")
+
+	// the exact subject isn't widened by the wildcards
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.remove", []string{"admin"}).ErrCode, nil)
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.remove", []string{"user"}).ErrCode, 100100)
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.remove", []string{"manager"}).ErrCode, 100100)
+	// the pattern with more literal tokens comes first
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.add", []string{"manager"}).ErrCode, nil)
+	AssertEqual(t, Authorize("bsl.fin-trans.trip.add", []string{"user"}).ErrCode, 100100)
+	AssertEqual(t, Authorize("bsl.notification.add", []string{"user"}).ErrCode, nil)
+}
+
+func TestPolicyMatchSubject(t *testing.T) {
+	AssertEqual(t, MatchSubject("bsl.>", "bsl.fin-trans.trip.add"), true)
+	AssertEqual(t, MatchSubject("bsl.>", "bsl"), false)
+	AssertEqual(t, MatchSubject("bsl.*.add", "bsl.fin-trans.add"), true)
+	AssertEqual(t, MatchSubject("bsl.*.add", "bsl.fin-trans.trip.add"), false)
+	AssertEqual(t, MatchSubject("bsl.fin-trans.trip.add", "bsl.fin-trans.trip.add"), true)
+	AssertEqual(t, MatchSubject("bsl.fin-trans.trip", "bsl.fin-trans.trip.add"), false)
+}
+
+func TestPolicyClaimRoles(t *testing.T) {
+	AssertEqual(t, strings.Join(ClaimRoles(jwt.MapClaims{GROUPSCLAIM: []interface{}{"admin", "10036"}}), ","), "admin,10036")
+	AssertEqual(t, len(ClaimRoles(jwt.MapClaims{})), 0)
+}
+
+func TestPolicyValidateRoles(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	token, _ := issuer.Token(map[string]interface{}{GROUPSCLAIM: []string{"admin"}})
+	roles, soteErr := ValidateRoles(RequestHeaderSchema{JsonWebToken: token, RoleList: []string{"driver"}}, ISSUERENVIRONMENT, true)
+	AssertEqual(t, soteErr.ErrCode, nil)
+	AssertEqual(t, strings.Join(roles, ","), "admin")
+	// the role-list isn't trusted, even in test mode
+	_, soteErr = ValidateRoles(RequestHeaderSchema{RoleList: []string{"admin"}}, sConfigParams.STAGING, true)
+	AssertEqual(t, soteErr.ErrCode != nil, true)
+}
@@ -0,0 +1,183 @@
+package sAuthentication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lestrrat-go/jwx/jwk"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// IdentityProvider verifies the tokens of an environment, CognitoProvider is used when the environment has no provider
+type IdentityProvider interface {
+	// Key returns the public key of the kid that signed the token
+	Key(kid string) (jwk.Key, sError.SoteError)
+	// ValidateClaims checks the claims of a token signed by the provider
+	ValidateClaims(claims jwt.MapClaims) sError.SoteError
+}
+
+// issuerKeyProvider is implemented by the identity providers chaining the keys of several issuers, the key of the kid is
+// only returned for the tokens of its issuer (iss)
+type issuerKeyProvider interface {
+	IssuerKey(issuer, kid string) (jwk.Key, sError.SoteError)
+}
+
+var (
+	providers   = map[string]IdentityProvider{} // environment -> provider
+	providersMu sync.RWMutex
+)
+
+// SetIdentityProvider selects the provider of the environment, a nil provider restores the Cognito user pool of the environment
+func SetIdentityProvider(tEnvironment string, provider IdentityProvider) {
+	sLogger.DebugMethod()
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if provider == nil {
+		delete(providers, tEnvironment)
+	} else {
+		providers[tEnvironment] = provider
+	}
+}
+
+// providerKey returns the key of the kid that signed the token of the issuer, Key of the provider when it doesn't implement IssuerKey
+func providerKey(provider IdentityProvider, issuer, kid string) (jwk.Key, sError.SoteError) {
+	if p, ok := provider.(issuerKeyProvider); ok {
+		return p.IssuerKey(issuer, kid)
+	}
+	return provider.Key(kid)
+}
+
+func identityProvider(tEnvironment string) IdentityProvider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	if provider, ok := providers[tEnvironment]; ok {
+		return provider
+	}
+	return CognitoProvider{Environment: tEnvironment}
+}
+
+// CognitoProvider verifies the access tokens of the Cognito user pool of the environment (see sConfigParams.GetUserPoolId)
+type CognitoProvider struct {
+	Environment string
+}
+
+func (p CognitoProvider) Key(kid string) (jwk.Key, sError.SoteError) {
+	return matchKid(p.Environment, kid)
+}
+
+// Algorithms allows RS256, the only algorithm of the Cognito user pools
+func (p CognitoProvider) Algorithms() []string {
+	return []string{jwt.SigningMethodRS256.Alg()}
+}
+
+func (p CognitoProvider) ValidateClaims(claims jwt.MapClaims) sError.SoteError {
+	return validateClaims(claims, p.Environment)
+}
+
+// OIDCProvider verifies the tokens of an OpenID Connect issuer. The keys are read from JWKSFile, from JWKSURL or from the
+// well-known JWKS of the issuer. RequiredClaims must be in the token, a claim with a value must have the value (or contain it).
+// AllowedAlgorithms restricts the algorithms of the tokens (default: DEFAULTALGORITHMS).
+type OIDCProvider struct {
+	Issuer            string
+	Audience          string
+	JWKSURL           string
+	JWKSFile          string
+	RequiredClaims    map[string]interface{}
+	AllowedAlgorithms []string
+	keySet            jwk.Set
+	once              sync.Once
+	soteErr           sError.SoteError
+}
+
+func (p *OIDCProvider) Key(kid string) (key jwk.Key, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var err error
+	if p.JWKSFile != "" {
+		p.once.Do(p.readKeySet)
+		if soteErr = p.soteErr; soteErr.ErrCode == nil {
+			if key, _ = p.keySet.LookupKeyID(kid); key == nil {
+				soteErr = sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
+			}
+		}
+		return
+	}
+	if p.JWKSURL != "" {
+		jwks.setURL(p.Issuer, p.JWKSURL)
+	}
+	if key, err = jwks.lookup(p.Issuer, kid); err != nil {
+		soteErr = sError.GetSError(210030, sError.BuildParams([]string{"OIDC", p.Issuer}), sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+	} else if key == nil {
+		soteErr = sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
+	}
+	return
+}
+
+func (p *OIDCProvider) Algorithms() []string {
+	return p.AllowedAlgorithms
+}
+
+func (p *OIDCProvider) readKeySet() {
+	if data, err := ioutil.ReadFile(p.JWKSFile); err != nil {
+		p.soteErr = sError.GetSError(209010, sError.BuildParams([]string{p.JWKSFile, err.Error()}), sError.EmptyMap)
+	} else if p.keySet, err = jwk.Parse(data); err != nil {
+		p.soteErr = sError.GetSError(207110, sError.BuildParams([]string{p.JWKSFile}), sError.EmptyMap)
+	}
+}
+
+func (p *OIDCProvider) ValidateClaims(claims jwt.MapClaims) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		invalid []string
+		missing bool
+	)
+	if claims["iss"] != p.Issuer {
+		soteErr = sError.GetSError(208300, nil, sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+		return
+	}
+	if p.Audience != "" {
+		if soteErr = validateAudience(claims, p.Audience); soteErr.ErrCode != nil {
+			sLogger.Info(soteErr.FmtErrMsg)
+			return
+		}
+	}
+	names := make([]string, 0, len(p.RequiredClaims))
+	for name := range p.RequiredClaims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if claim, ok := claims[name]; !ok {
+			missing = true
+		} else if value := p.RequiredClaims[name]; value != nil && !claimContains(claim, value) {
+			invalid = append(invalid, name)
+		}
+	}
+	if missing {
+		soteErr = sError.GetSError(208370, nil, sError.EmptyMap)
+	} else if len(invalid) > 0 {
+		soteErr = sError.GetSError(208360, sError.BuildParams([]string{fmt.Sprint(invalid)}), sError.EmptyMap)
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
+
+// claimContains compares a claim with a value, a list claim (e.g. aud or scope) must contain the value
+func claimContains(claim, value interface{}) bool {
+	if list, ok := claim.([]interface{}); ok {
+		for _, c := range list {
+			if fmt.Sprint(c) == fmt.Sprint(value) {
+				return true
+			}
+		}
+		return false
+	}
+	return claim != nil && fmt.Sprint(claim) == fmt.Sprint(value)
+}
@@ -0,0 +1,92 @@
+package sAuthentication
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+const (
+	OIDCENVIRONMENT = "oidc"
+	OIDCISSUER      = "https://oidc.example.com"
+)
+
+func newProviderKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	AssertEqual(t, err, nil)
+	key, _ := jwk.New(privateKey.Public())
+	key.Set(jwk.KeyIDKey, "oidc-kid")
+	keySet := jwk.NewSet()
+	keySet.Add(key)
+	data, _ := json.Marshal(keySet)
+	return privateKey, data
+}
+
+func signProviderToken(t *testing.T, privateKey *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "oidc-kid"
+	rawToken, err := token.SignedString(privateKey)
+	AssertEqual(t, err, nil)
+	return rawToken
+}
+
+func TestProviderOIDCFile(t *testing.T) {
+	privateKey, data := newProviderKey(t)
+	dir, _ := ioutil.TempDir("", "jwks")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "jwks.json"), data, 0644)
+	SetIdentityProvider(OIDCENVIRONMENT, &OIDCProvider{
+		Issuer:         OIDCISSUER,
+		Audience:       "bsl",
+		JWKSFile:       filepath.Join(dir, "jwks.json"),
+		RequiredClaims: map[string]interface{}{"sub": nil, "scope": "trips"},
+	})
+	defer SetIdentityProvider(OIDCENVIRONMENT, nil)
+
+	claims := jwt.MapClaims{"iss": OIDCISSUER, "aud": []interface{}{"bsl"}, "sub": "user", "scope": []interface{}{"trips"},
+		"exp": time.Now().Add(time.Hour).Unix()}
+	AssertEqual(t, ValidToken(OIDCENVIRONMENT, signProviderToken(t, privateKey, claims)).ErrCode, nil)
+
+	claims["aud"] = "other"
+	soteErr := ValidToken(OIDCENVIRONMENT, signProviderToken(t, privateKey, claims))
+	AssertEqual(t, soteErr.ErrCode, 208360)
+	AssertEqual(t, soteErr.ErrorDetails["expected"], "bsl")
+	claims["aud"] = "bsl"
+	delete(claims, "sub")
+	AssertEqual(t, ValidToken(OIDCENVIRONMENT, signProviderToken(t, privateKey, claims)).ErrCode, 208370)
+	claims["iss"] = "https://cognito-idp.eu-west-1.amazonaws.com/pool"
+	AssertEqual(t, ValidToken(OIDCENVIRONMENT, signProviderToken(t, privateKey, claims)).ErrCode, 208300)
+
+	otherKey, _ := newProviderKey(t)
+	claims["iss"], claims["sub"] = OIDCISSUER, "user"
+	AssertEqual(t, ValidToken(OIDCENVIRONMENT, signProviderToken(t, otherKey, claims)).ErrCode, 208357) // signed with another key
+}
+
+func TestProviderOIDCURL(t *testing.T) {
+	privateKey, data := newProviderKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AssertEqual(t, r.URL.Path, "/keys")
+		w.Write(data)
+	}))
+	defer server.Close()
+	SetIdentityProvider(OIDCENVIRONMENT, &OIDCProvider{Issuer: server.URL, JWKSURL: server.URL + "/keys"})
+	defer SetIdentityProvider(OIDCENVIRONMENT, nil)
+
+	claims := jwt.MapClaims{"iss": server.URL, "exp": time.Now().Add(time.Hour).Unix()}
+	AssertEqual(t, ValidToken(OIDCENVIRONMENT, signProviderToken(t, privateKey, claims)).ErrCode, nil)
+}
+
+func TestProviderDefault(t *testing.T) {
+	_, ok := identityProvider(OIDCENVIRONMENT).(CognitoProvider)
+	AssertEqual(t, ok, true)
+}
@@ -0,0 +1,173 @@
+package sAuthentication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const REVOCATIONREFRESH = time.Minute
+
+// Revocation blocks the token with the jti, or the tokens of the username issued before IssuedBefore (e.g. a disabled user)
+type Revocation struct {
+	TokenId      string    `json:"jti,omitempty"`
+	Username     string    `json:"username,omitempty"`
+	IssuedBefore time.Time `json:"issued-before,omitempty"`
+	ExpiresAt    time.Time `json:"expires-at,omitempty"` // the revoked tokens are expired after, zero never expires
+}
+
+// RevocationStore keeps the revocation list shared by the services, e.g. a JetStream KV bucket or a Postgres table
+type RevocationStore interface {
+	// Revocations returns the revocations that aren't expired
+	Revocations() ([]Revocation, sError.SoteError)
+	// Revoke saves the revocation, it replaces the revocation with the same Key
+	Revoke(revocation Revocation) sError.SoteError
+}
+
+// revocationCache is the local copy of the revocation list, it is reloaded from the store after the refresh interval.
+// The store is called without holding mu, the loaded maps are swapped in under mu.
+type revocationCache struct {
+	refresh  time.Duration
+	mu       sync.RWMutex
+	store    RevocationStore
+	tokens   map[string]Revocation // jti -> revocation
+	users    map[string]Revocation // username -> revocation
+	loadedAt time.Time
+	loading  bool
+	revoked  []Revocation // revoked while loading, the loaded list may miss them
+	now      func() time.Time
+}
+
+var revocations = &revocationCache{refresh: REVOCATIONREFRESH, now: time.Now}
+
+// Key identifies the revocation in the store, jti.<jti> or username.<username>
+func (r Revocation) Key() string {
+	if r.TokenId != "" {
+		return "jti." + r.TokenId
+	}
+	return "username." + r.Username
+}
+
+// SetRevocationStore checks the tokens against the revocation list of the store, the list is cached for refresh
+// (default: REVOCATIONREFRESH). A nil store disables the revocation checks.
+func SetRevocationStore(store RevocationStore, refresh time.Duration) {
+	sLogger.DebugMethod()
+	if refresh <= 0 {
+		refresh = REVOCATIONREFRESH
+	}
+	revocations.mu.Lock()
+	defer revocations.mu.Unlock()
+	revocations.store, revocations.refresh = store, refresh
+	revocations.tokens, revocations.users, revocations.loadedAt = nil, nil, time.Time{}
+	revocations.loading, revocations.revoked = false, nil
+}
+
+// Revoke saves the revocation in the store, the tokens of a username without IssuedBefore are revoked until now
+func Revoke(revocation Revocation) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	revocations.mu.RLock()
+	store, now := revocations.store, revocations.now
+	revocations.mu.RUnlock()
+	if store == nil {
+		return sError.GetSError(200513, sError.BuildParams([]string{"RevocationStore"}), sError.EmptyMap)
+	}
+	if revocation.TokenId == "" && revocation.Username == "" {
+		return sError.GetSError(200513, sError.BuildParams([]string{"jti or username"}), sError.EmptyMap)
+	}
+	if revocation.TokenId == "" && revocation.IssuedBefore.IsZero() {
+		revocation.IssuedBefore = now()
+	}
+	if soteErr = store.Revoke(revocation); soteErr.ErrCode == nil {
+		revocations.mu.Lock()
+		defer revocations.mu.Unlock()
+		if revocations.store == store {
+			if revocations.tokens != nil {
+				revocations.add(revocation)
+			}
+			if revocations.loading {
+				revocations.revoked = append(revocations.revoked, revocation)
+			}
+		}
+	}
+	return
+}
+
+// checkRevoked returns 208380 when the jti or the username of the token is revoked. The iat claim is in seconds, a token of
+// the username is revoked when its iat is before or in the same second as IssuedBefore (inclusive): a token issued within
+// the second of the revocation is rejected rather than a token issued before the revocation accepted.
+func checkRevoked(claims jwt.MapClaims) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	revocations.load()
+	revocations.mu.RLock()
+	defer revocations.mu.RUnlock()
+	if revocations.store == nil {
+		return
+	}
+	jti, _ := claims["jti"].(string)
+	identity := ClaimIdentity(claims)
+	if revocation, ok := revocations.tokens[jti]; ok && jti != "" && !revocations.expired(revocation) {
+		soteErr = sError.GetSError(208380, nil, map[string]string{"jti": jti})
+	} else if revocation, ok := revocations.users[identity.Username]; ok && identity.Username != "" && !revocations.expired(revocation) {
+		issuedAt, hasIat, _ := timeClaim(claims, "iat")
+		if !hasIat || !issuedAt.After(time.Unix(revocation.IssuedBefore.Unix(), 0)) {
+			soteErr = sError.GetSError(208380, nil, map[string]string{"username": identity.Username,
+				"issued-before": revocation.IssuedBefore.UTC().Format(time.RFC3339)})
+		}
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
+
+// load reloads the revocation list after the refresh interval, the previous list is kept when the store fails. The other
+// callers keep checking the previous list while the store is read.
+func (c *revocationCache) load() {
+	c.mu.Lock()
+	store := c.store
+	if store == nil || c.loading || c.now().Sub(c.loadedAt) < c.refresh {
+		c.mu.Unlock()
+		return
+	}
+	c.loadedAt, c.loading = c.now(), true // the store is retried after the refresh interval
+	c.mu.Unlock()
+
+	list, soteErr := store.Revocations()
+	tokens, users := map[string]Revocation{}, map[string]Revocation{}
+	for _, revocation := range list {
+		addRevocation(tokens, users, revocation)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.store != store { // the store was replaced while loading
+		return
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+	} else {
+		for _, revocation := range c.revoked {
+			addRevocation(tokens, users, revocation)
+		}
+		c.tokens, c.users = tokens, users
+	}
+	c.loading, c.revoked = false, nil
+}
+
+func (c *revocationCache) add(revocation Revocation) {
+	addRevocation(c.tokens, c.users, revocation)
+}
+
+func addRevocation(tokens, users map[string]Revocation, revocation Revocation) {
+	if revocation.TokenId != "" {
+		tokens[revocation.TokenId] = revocation
+	} else if current, ok := users[revocation.Username]; !ok || revocation.IssuedBefore.After(current.IssuedBefore) {
+		users[revocation.Username] = revocation
+	}
+}
+
+func (c *revocationCache) expired(revocation Revocation) bool {
+	return !revocation.ExpiresAt.IsZero() && c.now().After(revocation.ExpiresAt)
+}
@@ -0,0 +1,145 @@
+package sAuthentication
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+type memoryRevocations struct {
+	list  map[string]Revocation
+	loads int
+	err   sError.SoteError
+}
+
+func (m *memoryRevocations) Revocations() (list []Revocation, soteErr sError.SoteError) {
+	m.loads++
+	for _, revocation := range m.list {
+		list = append(list, revocation)
+	}
+	return list, m.err
+}
+
+func (m *memoryRevocations) Revoke(revocation Revocation) sError.SoteError {
+	m.list[revocation.Key()] = revocation
+	return sError.SoteError{}
+}
+
+func TestRevocationToken(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	AssertEqual(t, Revoke(Revocation{TokenId: "token-1"}).ErrCode, 200513)
+	store := &memoryRevocations{list: map[string]Revocation{}}
+	SetRevocationStore(store, time.Hour)
+	defer SetRevocationStore(nil, 0)
+
+	token, _ := issuer.Token(map[string]interface{}{"jti": "token-1"})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+	AssertEqual(t, Revoke(Revocation{TokenId: "token-1"}).FmtErrMsg, "")
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208380)
+	other, _ := issuer.Token(map[string]interface{}{"jti": "token-2"})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, other).ErrCode, nil)
+	AssertEqual(t, store.loads, 1)
+	AssertEqual(t, Revoke(Revocation{}).ErrCode, 200513)
+
+	// the revocations of the other services are loaded after the refresh interval
+	store.list["jti.token-2"] = Revocation{TokenId: "token-2"}
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, other).ErrCode, nil)
+	revocations.loadedAt = time.Now().Add(-2 * time.Hour)
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, other).ErrCode, 208380)
+}
+
+func TestRevocationUsername(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	store := &memoryRevocations{list: map[string]Revocation{}}
+	SetRevocationStore(store, time.Hour)
+	defer SetRevocationStore(nil, 0)
+
+	now := time.Now()
+	token, _ := issuer.Token(map[string]interface{}{"iat": now.Add(-time.Minute).Unix()})
+	AssertEqual(t, Revoke(Revocation{Username: "soteuser"}).FmtErrMsg, "")
+	soteErr := ValidToken(ISSUERENVIRONMENT, token)
+	AssertEqual(t, soteErr.ErrCode, 208380)
+	AssertEqual(t, soteErr.ErrorDetails["username"], "soteuser")
+	// the tokens issued after the revocation are valid
+	token, _ = issuer.Token(map[string]interface{}{"iat": now.Add(time.Second).Unix()})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+
+	// the expired revocations are ignored
+	store.list["username.soteuser"] = Revocation{Username: "soteuser", IssuedBefore: now, ExpiresAt: now.Add(-time.Second)}
+	revocations.loadedAt = time.Time{}
+	token, _ = issuer.Token(map[string]interface{}{"iat": now.Add(-time.Minute).Unix()})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+}
+
+func TestRevocationStoreError(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	store := &memoryRevocations{list: map[string]Revocation{"jti.token-1": {TokenId: "token-1"}}}
+	SetRevocationStore(store, time.Hour)
+	defer SetRevocationStore(nil, 0)
+	token, _ := issuer.Token(map[string]interface{}{"jti": "token-1"})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208380)
+
+	// the cached list is kept when the store fails
+	store.err = sError.GetSError(209299, nil, sError.EmptyMap)
+	revocations.loadedAt = time.Time{}
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208380)
+	AssertEqual(t, store.loads, 2)
+}
+
+func TestRevocationUsernameSameSecond(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	SetRevocationStore(&memoryRevocations{list: map[string]Revocation{}}, time.Hour)
+	defer SetRevocationStore(nil, 0)
+	// the user is disabled in the middle of a second, iat only has the seconds
+	now := time.Unix(time.Now().Unix(), int64(500*time.Millisecond))
+	AssertEqual(t, Revoke(Revocation{Username: "soteuser", IssuedBefore: now}).FmtErrMsg, "")
+
+	token, _ := issuer.Token(map[string]interface{}{"iat": now.Unix()})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208380)
+	token, _ = issuer.Token(map[string]interface{}{"iat": now.Unix() + 1})
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+}
+
+type blockingRevocations struct {
+	memoryRevocations
+	started, release chan struct{}
+}
+
+func (b *blockingRevocations) Revocations() ([]Revocation, sError.SoteError) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.memoryRevocations.Revocations()
+}
+
+func TestRevocationLoadUnlocked(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(ISSUERENVIRONMENT, issuer)
+	defer SetIdentityProvider(ISSUERENVIRONMENT, nil)
+	store := &blockingRevocations{memoryRevocations{list: map[string]Revocation{}}, make(chan struct{}), make(chan struct{})}
+	SetRevocationStore(store, time.Hour)
+	defer SetRevocationStore(nil, 0)
+	token, _ := issuer.Token(map[string]interface{}{"jti": "token-1"})
+
+	done := make(chan struct{})
+	go func() {
+		revocations.load()
+		close(done)
+	}()
+	<-store.started
+	// the tokens are checked and revoked while the store is read
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, nil)
+	AssertEqual(t, Revoke(Revocation{TokenId: "token-1"}).FmtErrMsg, "")
+	delete(store.list, "jti.token-1") // the store is read before the revocation is saved
+	close(store.release)
+	<-done
+	AssertEqual(t, ValidToken(ISSUERENVIRONMENT, token).ErrCode, 208380)
+}
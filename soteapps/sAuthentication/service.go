@@ -0,0 +1,227 @@
+package sAuthentication
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/nats-io/nkeys"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	SERVICETOKENHEADER  = "service-token" // NATS header of the token of the calling service
+	SERVICETOKENEXPIRES = time.Minute
+)
+
+// ServiceTokenStore records the jti of the accepted service tokens shared by the replicas of a service, e.g. a JetStream KV
+// bucket. Without store a token replayed to another replica is accepted again.
+type ServiceTokenStore interface {
+	// Accept records the jti until expiresAt, found reports a jti that was already accepted
+	Accept(jti string, expiresAt time.Time) (found bool, soteErr sError.SoteError)
+}
+
+type trustedService struct {
+	key   jwk.Key
+	roles []string
+}
+
+var (
+	services   = map[string]trustedService{} // service name -> public key
+	servicesMu sync.RWMutex
+	// jti -> exp of the accepted service tokens, a service token is only accepted once
+	serviceTokenIds      = map[string]time.Time{}
+	serviceTokenIdsMu    sync.Mutex
+	serviceTokenPrunedAt time.Time
+	serviceTokenStore    ServiceTokenStore
+)
+
+// TrustService accepts the service tokens of the service, publicKey is a NKey public key (e.g. the U... key of the NATS
+// user of the service) or a crypto.PublicKey. The roles are the groups of the service identity (see AddPolicy).
+func TrustService(name string, publicKey interface{}, roles ...string) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		key jwk.Key
+		err error
+	)
+	if nkey, ok := publicKey.(string); ok {
+		var raw []byte
+		if raw, err = nkeys.Decode(nkeys.Prefix(nkey), []byte(nkey)); err == nil {
+			publicKey = ed25519.PublicKey(raw)
+		}
+	}
+	if err == nil {
+		key, err = jwk.New(publicKey)
+	}
+	if err != nil {
+		soteErr = sError.GetSError(207110, sError.BuildParams([]string{name}), sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+		return
+	}
+	servicesMu.Lock()
+	defer servicesMu.Unlock()
+	services[name] = trustedService{key: key, roles: roles}
+	return
+}
+
+func DistrustService(name string) {
+	servicesMu.Lock()
+	defer servicesMu.Unlock()
+	delete(services, name)
+}
+
+// NKeySigner returns the private key of a NKey seed or of the NKey of a NATS credentials file
+func NKeySigner(seed []byte) (signer crypto.Signer, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		keyPair nkeys.KeyPair
+		raw     []byte
+		err     error
+	)
+	if keyPair, err = nkeys.ParseDecoratedNKey(seed); err == nil {
+		if seed, err = keyPair.Seed(); err == nil {
+			_, raw, err = nkeys.DecodeSeed(seed)
+		}
+	}
+	if err != nil {
+		soteErr = sError.GetSError(207110, sError.BuildParams([]string{"NKey seed"}), sError.EmptyMap)
+		sLogger.Info(soteErr.FmtErrMsg)
+		return
+	}
+	return ed25519.NewKeyFromSeed(raw), soteErr
+}
+
+// ServiceToken signs a short-lived token of the service for the messages of the subject, the algorithm is EdDSA for a
+// NKey, ES256 for an ECDSA key and RS256 for a RSA key. The aws-user-name and organizations-id of onBehalfOf are signed in
+// the username and custom:organizations-id claims, the receiver rejects a request header of another user or organization.
+func ServiceToken(name, subject string, signer crypto.Signer, onBehalfOf ...RequestHeaderSchema) (rawToken string,
+	soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", sError.GetSError(210599, nil, map[string]string{"ERROR": err.Error()})
+	}
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	switch signer.(type) {
+	case ed25519.PrivateKey:
+		method = SigningMethodEdDSA
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": name,
+		"sub": name,
+		"aud": subject,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(SERVICETOKENEXPIRES).Unix(),
+	}
+	for _, rh := range onBehalfOf {
+		if rh.AwsUserName != "" {
+			claims["username"] = rh.AwsUserName
+		}
+		if rh.OrganizationId != 0 {
+			claims[ORGANIZATIONCLAIM] = strconv.Itoa(rh.OrganizationId)
+		}
+	}
+	token := jwt.NewWithClaims(method, claims)
+	var err error
+	if rawToken, err = token.SignedString(signer); err != nil {
+		soteErr = sError.GetSError(210599, nil, map[string]string{"ERROR": err.Error()})
+	}
+	return
+}
+
+// ValidateServiceToken verifies the token of a trusted service, the audience of the token must be the subject of the message
+// and the jti is only accepted once. The username and organization of the identity are the user the service acts for.
+func ValidateServiceToken(rawToken, subject string) (identity *Identity, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var service trustedService
+	token, parseErr := parseToken(rawToken, func(token *jwt.Token) (interface{}, error) {
+		var ok bool
+		claims, _ := token.Claims.(jwt.MapClaims)
+		name, _ := claims["iss"].(string)
+		servicesMu.RLock()
+		service, ok = services[name]
+		servicesMu.RUnlock()
+		if !ok {
+			soteErr = sError.GetSError(208300, nil, sError.EmptyMap)
+		} else if soteErr = checkAlgorithm(nil, token, service.key); soteErr.ErrCode == nil {
+			var raw interface{}
+			return raw, service.key.Raw(&raw)
+		}
+		return nil, nil
+	})
+	if soteErr.ErrCode == nil {
+		soteErr = parseErr
+	}
+	if soteErr.ErrCode == nil {
+		claims := token.Claims.(jwt.MapClaims)
+		if soteErr = validateTimes(claims); soteErr.ErrCode == nil {
+			soteErr = validateAudience(claims, subject)
+		}
+		if soteErr.ErrCode == nil {
+			identity = ClaimIdentity(claims)
+			identity.ClientId, identity.Service, identity.Groups = identity.Subject, identity.Subject, service.roles
+			soteErr = acceptServiceToken(claims, identity.ExpiresAt)
+		}
+		if soteErr.ErrCode != nil {
+			identity = nil
+		}
+	}
+	if soteErr.ErrCode != nil {
+		sLogger.Info(soteErr.FmtErrMsg)
+	}
+	return
+}
+
+// SetServiceTokenStore shares the jti of the accepted service tokens with the other replicas through the store, nil keeps
+// them in this replica. The jti of this replica are checked while the store fails.
+func SetServiceTokenStore(store ServiceTokenStore) {
+	sLogger.DebugMethod()
+	serviceTokenIdsMu.Lock()
+	defer serviceTokenIdsMu.Unlock()
+	serviceTokenStore = store
+}
+
+// acceptServiceToken records the jti of the token until it expires, a replayed token is rejected with 208360
+func acceptServiceToken(claims jwt.MapClaims, expiresAt time.Time) (soteErr sError.SoteError) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return sError.GetSError(208370, nil, sError.EmptyMap)
+	}
+	now := time.Now()
+	serviceTokenIdsMu.Lock()
+	defer serviceTokenIdsMu.Unlock()
+	if serviceTokenStore != nil {
+		found, storeErr := serviceTokenStore.Accept(jti, expiresAt.Add(leeway))
+		if found {
+			return sError.GetSError(208360, sError.BuildParams([]string{"[jti]"}), map[string]string{"jti": jti})
+		}
+		if storeErr.ErrCode != nil {
+			sLogger.Info(storeErr.FmtErrMsg)
+		}
+	}
+	if now.Sub(serviceTokenPrunedAt) > SERVICETOKENEXPIRES {
+		for id, expires := range serviceTokenIds {
+			if now.After(expires) {
+				delete(serviceTokenIds, id)
+			}
+		}
+		serviceTokenPrunedAt = now
+	}
+	if _, found := serviceTokenIds[jti]; found {
+		return sError.GetSError(208360, sError.BuildParams([]string{"[jti]"}), map[string]string{"jti": jti})
+	}
+	serviceTokenIds[jti] = expiresAt.Add(leeway) // the token is accepted until exp plus the leeway
+	return
+}
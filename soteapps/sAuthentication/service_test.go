@@ -0,0 +1,130 @@
+package sAuthentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/nats-io/nkeys"
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+func TestServiceTokenNKey(t *testing.T) {
+	keyPair, _ := nkeys.CreateUser()
+	seed, _ := keyPair.Seed()
+	publicKey, _ := keyPair.PublicKey()
+	signer, soteErr := NKeySigner([]byte("-----BEGIN USER NKEY SEED-----\n" + string(seed) + "\n------END USER NKEY SEED------\n"))
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, TrustService("billing", publicKey, "service", "admin").FmtErrMsg, "")
+	defer DistrustService("billing")
+
+	token, soteErr := ServiceToken("billing", "bsl.fin-trans.trip.add", signer)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	identity, soteErr := ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, identity.Service, "billing")
+	AssertEqual(t, strings.Join(identity.Groups, ","), "service,admin")
+	AssertEqual(t, identity.Match(RequestHeaderSchema{}).FmtErrMsg, "")
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "soteuser"}).ErrCode, 208360)
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.ErrCode, 208360) // the token was replayed
+
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.remove")
+	AssertEqual(t, soteErr.ErrCode, 208360)
+	DistrustService("billing")
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.ErrCode, 208300)
+}
+
+func TestServiceTokenKeyPair(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	AssertEqual(t, TrustService("trips", &privateKey.PublicKey).FmtErrMsg, "")
+	defer DistrustService("trips")
+
+	// the groups of the token aren't the roles of the service
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": "trips", "sub": "trips", "aud": "bsl.trip", "jti": "trips-1",
+		"exp": 4102444800, GROUPSCLAIM: []string{"admin"}})
+	rawToken, _ := token.SignedString(privateKey)
+	identity, soteErr := ValidateServiceToken(rawToken, "bsl.trip")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, len(identity.Groups), 0)
+
+	token = jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": "trips", "aud": "bsl.trip"})
+	rawToken, _ = token.SignedString(privateKey)
+	_, soteErr = ValidateServiceToken(rawToken, "bsl.trip")
+	AssertEqual(t, soteErr.ErrCode, 208370)
+	token = jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": "trips", "aud": "bsl.trip", "exp": 4102444800})
+	rawToken, _ = token.SignedString(privateKey)
+	_, soteErr = ValidateServiceToken(rawToken, "bsl.trip")
+	AssertEqual(t, soteErr.ErrCode, 208370) // the jti is missing
+	token = jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": "trips", "aud": "bsl.trip", "exp": 1})
+	rawToken, _ = token.SignedString(privateKey)
+	_, soteErr = ValidateServiceToken(rawToken, "bsl.trip")
+	AssertEqual(t, soteErr.ErrCode, 208350)
+
+	AssertEqual(t, TrustService("invalid", "UNOTANKEY").ErrCode, 207110)
+}
+
+func TestServiceTokenOnBehalfOf(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	AssertEqual(t, TrustService("trips", &privateKey.PublicKey).FmtErrMsg, "")
+	defer DistrustService("trips")
+
+	token, _ := ServiceToken("trips", "bsl.trip", privateKey, RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003})
+	identity, soteErr := ValidateServiceToken(token, "bsl.trip")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, identity.Username, "soteuser")
+	AssertEqual(t, identity.OrganizationId, 10003)
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003}).FmtErrMsg, "")
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "other", OrganizationId: 10003}).ErrCode, 208360)
+	AssertEqual(t, identity.Match(RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10004}).ErrCode, 208360)
+}
+
+type fakeServiceTokenStore struct {
+	ids map[string]time.Time
+	err sError.SoteError
+}
+
+func (f *fakeServiceTokenStore) Accept(jti string, expiresAt time.Time) (found bool, soteErr sError.SoteError) {
+	if f.err.ErrCode != nil {
+		return false, f.err
+	}
+	_, found = f.ids[jti]
+	f.ids[jti] = expiresAt
+	return
+}
+
+func TestServiceTokenStore(t *testing.T) {
+	keyPair, _ := nkeys.CreateUser()
+	seed, _ := keyPair.Seed()
+	publicKey, _ := keyPair.PublicKey()
+	signer, _ := NKeySigner(seed)
+	TrustService("billing", publicKey)
+	defer DistrustService("billing")
+	store := &fakeServiceTokenStore{ids: map[string]time.Time{}}
+	SetServiceTokenStore(store)
+	defer SetServiceTokenStore(nil)
+
+	token, _ := ServiceToken("billing", "bsl.fin-trans.trip.add", signer)
+	_, soteErr := ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, len(store.ids), 1)
+	// another replica has no jti of its own, the store rejects the replayed token
+	serviceTokenIdsMu.Lock()
+	serviceTokenIds = map[string]time.Time{}
+	serviceTokenIdsMu.Unlock()
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.ErrCode, 208360)
+
+	// the jti of the replica are checked while the store fails
+	store.err = sError.GetSError(209499, nil, sError.EmptyMap)
+	token, _ = ServiceToken("billing", "bsl.fin-trans.trip.add", signer)
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	_, soteErr = ValidateServiceToken(token, "bsl.fin-trans.trip.add")
+	AssertEqual(t, soteErr.ErrCode, 208360)
+}
@@ -1,9 +1,6 @@
 package sAuthentication
 
 import (
-	"context"
-	"strings"
-
 	"github.com/dgrijalva/jwt-go"
 	"github.com/lestrrat-go/jwx/jwk"
 	"gitlab.com/soteapps/packages/v2021/sConfigParams"
@@ -12,57 +9,58 @@ import (
 )
 
 func ValidToken(tEnvironment, rawToken string) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	_, soteErr = ValidTokenClaims(tEnvironment, rawToken)
+	return
+}
+
+/*
+This validates the token like ValidToken and returns the claims of the verified token
+*/
+func ValidTokenClaims(tEnvironment, rawToken string) (claims jwt.MapClaims, soteErr sError.SoteError) {
 	sLogger.DebugMethod()
 	if tEnvironment != "" && rawToken != "" {
-		if len(strings.Split(rawToken, ".")) == 3 {
-			token, err := jwt.Parse(rawToken, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-					soteErr = sError.GetSError(209500, nil, sError.EmptyMap)
+		provider := identityProvider(tEnvironment)
+		token, parseErr := parseToken(rawToken, func(token *jwt.Token) (interface{}, error) {
+			if soteErr = checkAlgorithm(provider, token, nil); soteErr.ErrCode == nil {
+				var (
+					kid string
+					ok  bool
+					key jwk.Key
+				)
+				if kid, ok = token.Header["kid"].(string); !ok {
+					soteErr = sError.GetSError(209510, nil, sError.EmptyMap)
 				}
 
 				if soteErr.ErrCode == nil {
-					var (
-						kid string
-						ok  bool
-						key jwk.Key
-					)
-					if kid, ok = token.Header["kid"].(string); !ok {
-						soteErr = sError.GetSError(209510, nil, sError.EmptyMap)
-					}
-
-					if soteErr.ErrCode == nil {
-						if key, soteErr = matchKid(tEnvironment, kid); soteErr.ErrCode == nil {
+					issuer, _ := token.Claims.(jwt.MapClaims)["iss"].(string)
+					if key, soteErr = providerKey(provider, issuer, kid); soteErr.ErrCode == nil {
+						if soteErr = checkAlgorithm(provider, token, key); soteErr.ErrCode == nil {
 							var raw interface{}
 							return raw, key.Raw(&raw)
 						}
 					}
 				}
+			}
 
-				return nil, nil
-			})
+			return nil, nil
+		})
 
-			if err != nil {
-				if strings.Contains(err.Error(), "expired") {
-					soteErr = sError.GetSError(208350, nil, sError.EmptyMap)
-				}
-				if strings.Contains(err.Error(), "invalid type") || strings.Contains(err.Error(), "invalid character") {
-					soteErr = sError.GetSError(208355, nil, sError.EmptyMap)
-				}
-				if strings.Contains(err.Error(), "invalid number of segments") {
-					soteErr = sError.GetSError(208356, nil, sError.EmptyMap)
-				}
+		if soteErr.ErrCode == nil { // the key function errors come first
+			if soteErr = parseErr; soteErr.ErrCode != nil {
+				sLogger.Info(soteErr.FmtErrMsg)
 			}
+		}
 
-			if soteErr.ErrCode == nil {
-				if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-					soteErr = validateClaims(claims, tEnvironment)
-				} else {
-					soteErr = sError.GetSError(208355, nil, sError.EmptyMap)
+		if soteErr.ErrCode == nil {
+			tokenClaims := token.Claims.(jwt.MapClaims)
+			if soteErr = validateTimes(tokenClaims); soteErr.ErrCode == nil {
+				if soteErr = provider.ValidateClaims(tokenClaims); soteErr.ErrCode == nil {
+					if soteErr = checkRevoked(tokenClaims); soteErr.ErrCode == nil {
+						claims = tokenClaims
+					}
 				}
 			}
-		} else {
-			soteErr = sError.GetSError(208356, nil, sError.EmptyMap)
-			sLogger.Info(soteErr.FmtErrMsg)
 		}
 	} else {
 		soteErr = sError.GetSError(200512, sError.BuildParams([]string{"Environment", "Token"}), sError.EmptyMap)
@@ -76,33 +74,15 @@ func matchKid(tEnvironment, kid string) (key jwk.Key, soteErr sError.SoteError)
 	sLogger.DebugMethod()
 
 	var (
-		keySet jwk.Set
-		ok     bool
+		issuer string
+		err    error
 	)
-	keySet, soteErr = getPublicKey(tEnvironment)
-	key, ok = keySet.LookupKeyID(kid)
-	if !ok {
-		soteErr = sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
-	}
-
-	return
-}
-
-/*
-This will return the public key needed to validate the jwt token
-
-NOTE: If the region or user pool id is not found, getPublicKey will default to the 'eu-west-1' region
-and the userPoolId used by development instance of Cognito
-*/
-func getPublicKey(tEnvironment string) (keySet jwk.Set, soteErr sError.SoteError) {
-	sLogger.DebugMethod()
-
-	if region, soteErr := sConfigParams.GetRegion(); soteErr.ErrCode == nil {
-		if userPoolId, soteErr := sConfigParams.GetUserPoolId(tEnvironment); soteErr.ErrCode == nil {
-			if keySet, soteErr = fetchPublicKey(region, userPoolId, tEnvironment); soteErr.ErrCode != nil {
-				soteErr = sError.GetSError(210030, sError.BuildParams([]string{tEnvironment}), sError.EmptyMap)
-				panic(soteErr.FmtErrMsg)
-			}
+	if issuer, soteErr = cognitoIssuer(tEnvironment); soteErr.ErrCode == nil {
+		if key, err = jwks.lookup(issuer, kid); err != nil {
+			soteErr = sError.GetSError(210030, sError.BuildParams([]string{tEnvironment}), sError.EmptyMap)
+			sLogger.Info(soteErr.FmtErrMsg)
+		} else if key == nil {
+			soteErr = sError.GetSError(209521, sError.BuildParams([]string{kid}), sError.EmptyMap)
 		}
 	}
 
@@ -110,25 +90,10 @@ func getPublicKey(tEnvironment string) (keySet jwk.Set, soteErr sError.SoteError
 }
 
 /*
-This will pull the public key from the internet. The URL should not be output to logs.
+This returns the issuer of the Cognito user pool of the environment, it is cached with the JSON web keys
 */
-func fetchPublicKey(region, userPoolId, tEnvironment string) (keySet jwk.Set, soteErr sError.SoteError) {
-	sLogger.DebugMethod()
-
-	var (
-		err            error
-		keyPubLocation = "https://cognito-idp." + region + ".amazonaws.com/" + userPoolId + "/.well-known/jwks.json"
-	)
-
-	keySet, err = jwk.Fetch(context.Background(), keyPubLocation)
-	if err != nil {
-		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "remote JWK") {
-			soteErr = sError.GetSError(210030, sError.BuildParams([]string{region, tEnvironment}), sError.EmptyMap)
-			sLogger.Info(soteErr.FmtErrMsg)
-		}
-	}
-
-	return
+func cognitoIssuer(tEnvironment string) (issuer string, soteErr sError.SoteError) {
+	return jwks.issuer(tEnvironment)
 }
 
 /*
@@ -138,8 +103,8 @@ func validateClaims(claims jwt.MapClaims, tEnvironment string) (soteErr sError.S
 	sLogger.DebugMethod()
 
 	var (
-		claimCount         = 0
-		region, userPoolId string
+		claimCount = 0
+		issuer     string
 	)
 
 	for key, claim := range claims {
@@ -163,18 +128,12 @@ func validateClaims(claims jwt.MapClaims, tEnvironment string) (soteErr sError.S
 				}
 			case "iss":
 				claimCount++
-				if region, soteErr = sConfigParams.GetRegion(); soteErr.ErrCode == nil {
-					if userPoolId, soteErr = sConfigParams.GetUserPoolId(tEnvironment); soteErr.ErrCode == nil {
-						issuerURL := "https://cognito-idp." + region + ".amazonaws.com/" + userPoolId
-						if claim != issuerURL {
-							soteErr = sError.GetSError(208360, sError.BuildParams([]string{claim.(string)}), sError.EmptyMap)
-							sLogger.Info(soteErr.FmtErrMsg)
-						} else {
-							sLogger.Info("Claim (iss) was found")
-						}
-					} else {
+				if issuer, soteErr = cognitoIssuer(tEnvironment); soteErr.ErrCode == nil {
+					if claim != issuer {
 						soteErr = sError.GetSError(208360, sError.BuildParams([]string{claim.(string)}), sError.EmptyMap)
 						sLogger.Info(soteErr.FmtErrMsg)
+					} else {
+						sLogger.Info("Claim (iss) was found")
 					}
 				} else {
 					soteErr = sError.GetSError(208360, sError.BuildParams([]string{claim.(string)}), sError.EmptyMap)
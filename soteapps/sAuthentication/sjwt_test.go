@@ -4,8 +4,6 @@ import (
 	"testing"
 
 	"github.com/dgrijalva/jwt-go"
-	"github.com/lestrrat-go/jwx/jwk"
-
 	// "github.com/lestrrat-go/jwx/jwk"
 	"gitlab.com/soteapps/packages/v2021/sConfigParams"
 	"gitlab.com/soteapps/packages/v2021/sError"
@@ -55,8 +53,8 @@ func TestValidFakeToken(tPtr *testing.T) {
 }
 func TestInValidSignatureToken(tPtr *testing.T) {
 	var soteErr sError.SoteError
-	if soteErr = ValidToken(sConfigParams.DEVELOPMENT, TOKENINVALIDSIG); soteErr.ErrCode != 208350 && soteErr.ErrCode != 208355 && soteErr.ErrCode != 208356 {
-		tPtr.Errorf("ValidToken failed: Expected soteErr to be 208350, 208355 or 208356: %v", soteErr.FmtErrMsg)
+	if soteErr = ValidToken(sConfigParams.DEVELOPMENT, TOKENINVALIDSIG); soteErr.ErrCode != 208357 && soteErr.ErrCode != 208355 && soteErr.ErrCode != 208356 {
+		tPtr.Errorf("ValidToken failed: Expected soteErr to be 208357, 208355 or 208356: %v", soteErr.FmtErrMsg)
 	}
 }
 
@@ -81,42 +79,6 @@ func TestMatchKid(tPtr *testing.T) {
 		tPtr.Errorf("matchKid failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
 	}
 }
-func TestGetPublicKey(tPtr *testing.T) {
-	if keySet, soteErr := getPublicKey(sConfigParams.DEVELOPMENT); soteErr.ErrCode == nil {
-		if keySet.Len() == 0 {
-			tPtr.Errorf("matchKid failed: Expected keys count to be greater than zero: %v", keySet.Len())
-		}
-	} else {
-		tPtr.Errorf("matchKid failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
-	}
-}
-func TestFetchPublicKey(tPtr *testing.T) {
-	var (
-		region, userPoolId string
-		soteErr            sError.SoteError
-		keySet             jwk.Set
-	)
-
-	if region, soteErr = sConfigParams.GetRegion(); soteErr.ErrCode != nil {
-		tPtr.Fatalf("matchKid failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
-	}
-
-	if userPoolId, soteErr = sConfigParams.GetUserPoolId(sConfigParams.DEVELOPMENT); soteErr.ErrCode != nil {
-		tPtr.Fatalf("matchKid failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
-	}
-
-	if keySet, soteErr = fetchPublicKey(region, userPoolId, sConfigParams.DEVELOPMENT); soteErr.ErrCode == nil {
-		if keySet.Len() == 0 {
-			tPtr.Errorf("matchKid failed: Expected keys count to be greater than zero: %v", keySet.Len())
-		}
-	} else {
-		tPtr.Errorf("matchKid failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
-	}
-
-	if _, soteErr = fetchPublicKey("SCOTT_LAND", userPoolId, sConfigParams.DEVELOPMENT); soteErr.ErrCode != 210030 {
-		tPtr.Errorf("matchKid failed: Expected soteErr to be 210030: %v", soteErr.FmtErrMsg)
-	}
-}
 func TestValidateClaims(tPtr *testing.T) {
 	var claims jwt.MapClaims
 	if soteErr := validateClaims(claims, sConfigParams.STAGING); soteErr.ErrCode != 208370 {
@@ -2,25 +2,16 @@ package sAuthentication
 
 import (
 	"encoding/json"
-	"flag"
 	"fmt"
-	"io/ioutil"
-	"math"
-	"os"
-	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
-	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/nats-io/nats.go"
 	"gitlab.com/soteapps/packages/v2021/sError"
 	"gitlab.com/soteapps/packages/v2021/sLogger"
 )
 
-const DEVICE_FILE = ".git/device.info"
-const DEVICE_TIMEOUT = 30
-
 type RequestHeaderSchema struct {
 	JsonWebToken   string   `json:"json-web-token"`
 	MessageId      string   `json:"message-id"`
@@ -37,14 +28,18 @@ type RequestHeader struct {
 }
 
 func ValidateHeader(h nats.Header, tEnvironment string, isTestMode bool) (RequestHeaderSchema, sError.SoteError) {
-	rh := RequestHeader{}
-	rh.Header.JsonWebToken = h.Get("json-web-token")
-	rh.Header.MessageId = h.Get("message-id")
-	rh.Header.AwsUserName = h.Get("aws-user-name")
-	rh.Header.RoleList = strings.Split(regexp.MustCompile(`\[|\]`).ReplaceAllString(h.Get("role-list"), ""), ",")
-	fmt.Sscan(h.Get("organizations-id"), &rh.Header.OrganizationId)
-	fmt.Sscan(h.Get("device-id"), &rh.Header.DeviceId)
-	return Validate(rh, tEnvironment, isTestMode)
+	return Validate(RequestHeader{Header: HeaderRequest(h)}, tEnvironment, isTestMode)
+}
+
+// HeaderRequest reads the request header fields from the NATS headers of the message
+func HeaderRequest(h nats.Header) (header RequestHeaderSchema) {
+	header.JsonWebToken = h.Get("json-web-token")
+	header.MessageId = h.Get("message-id")
+	header.AwsUserName = h.Get("aws-user-name")
+	header.RoleList = strings.Split(regexp.MustCompile(`\[|\]`).ReplaceAllString(h.Get("role-list"), ""), ",")
+	fmt.Sscan(h.Get("organizations-id"), &header.OrganizationId)
+	fmt.Sscan(h.Get("device-id"), &header.DeviceId)
+	return
 }
 
 func ValidateBody(data []byte, tEnvironment string, isTestMode bool) (RequestHeaderSchema, sError.SoteError) {
@@ -61,7 +56,19 @@ func ValidateBody(data []byte, tEnvironment string, isTestMode bool) (RequestHea
 }
 
 func Validate(rh RequestHeader, tEnvironment string, isTestMode bool) (RequestHeaderSchema, sError.SoteError) {
-	soteErr := sError.SoteError{}
+	header, _, soteErr := ValidateIdentity(rh, tEnvironment, isTestMode)
+	return header, soteErr
+}
+
+// ValidateIdentity verifies the token of the request header and returns the identity of the token, the username and the
+// organization of the request header must be the ones of the token
+func ValidateIdentity(rh RequestHeader, tEnvironment string, isTestMode bool) (header RequestHeaderSchema, identity *Identity, soteErr sError.SoteError) {
+	return MatchIdentity(rh, nil, tEnvironment, isTestMode)
+}
+
+// MatchIdentity is ValidateIdentity with the identity of the token already verified for the message (see TokenIdentity),
+// the token is only validated when verified is nil
+func MatchIdentity(rh RequestHeader, verified *Identity, tEnvironment string, isTestMode bool) (header RequestHeaderSchema, identity *Identity, soteErr sError.SoteError) {
 	if rh.Header.AwsUserName == "" {
 		soteErr = sError.GetSError(206200, []interface{}{"#/properties/aws-user-name"}, nil)
 	} else if rh.Header.OrganizationId == 0 {
@@ -74,41 +81,30 @@ func Validate(rh RequestHeader, tEnvironment string, isTestMode bool) (RequestHe
 		}
 	}
 
-	if isTestMode {
-		isUnitest := flag.Lookup("test.count")
-		if isUnitest != nil { // go test ./...
-			val, _ := isUnitest.Value.(flag.Getter)
-			if val.Get().(uint) != 0 { //skip validation for unittests except srequest_test.go
-				return rh.Header, soteErr
-			}
+	if rh.Header.JsonWebToken == "" {
+		soteErr = sError.GetSError(208355, nil, nil)
+	} else {
+		//https://auth0.com/docs/tokens?_ga=2.253547273.1898510496.1593591557-1741611737.1593591372
+		if identity = verified; identity == nil {
+			identity, soteErr = TokenIdentity(rh.Header.JsonWebToken, tEnvironment, isTestMode)
 		}
-		if rh.Header.DeviceId != 0 { //access from test scripts
-			git, _ := filepath.Abs(".git")
-			os.MkdirAll(git, os.ModePerm)
-			path, _ := filepath.Abs(DEVICE_FILE)
-			fileData, err := ioutil.ReadFile(path)
-			if err != nil {
-				return rh.Header, sError.GetSError(209010, []interface{}{DEVICE_FILE, err.Error()}, nil)
-			} else {
-				t, err := strconv.ParseInt(strings.TrimSpace(string(fileData)), 10, 0)
-				if err != nil {
-					sLogger.Debug(err.Error())
-					return rh.Header, sError.GetSError(208355, nil, nil)
-				}
-				if t != rh.Header.DeviceId || math.Abs(float64(time.Now().Unix()-t)) >= DEVICE_TIMEOUT { //maxium duration of a device token
-					return rh.Header, sError.GetSError(208350, nil, nil)
-				} else {
-					return rh.Header, soteErr
-				}
+		if soteErr.ErrCode == nil {
+			if soteErr = identity.Match(rh.Header); soteErr.ErrCode != nil {
+				identity = nil
 			}
 		}
 	}
+	return rh.Header, identity, soteErr
+}
 
-	if rh.Header.JsonWebToken == "" {
-		soteErr = sError.GetSError(208355, nil, nil)
-	} else {
-		//https://auth0.com/docs/tokens?_ga=2.253547273.1898510496.1593591557-1741611737.1593591372
-		soteErr = ValidToken(tEnvironment, rh.Header.JsonWebToken)
+// TokenIdentity verifies the token and returns its identity, the request header isn't checked against it (see Identity.Match)
+func TokenIdentity(rawToken, tEnvironment string, isTestMode bool) (identity *Identity, soteErr sError.SoteError) {
+	var claims jwt.MapClaims
+	if isTestMode {
+		useLocalKeyFile(tEnvironment) // tokens of the local issuer, see LoadLocalIssuer
+	}
+	if claims, soteErr = ValidTokenClaims(tEnvironment, rawToken); soteErr.ErrCode == nil {
+		identity = ClaimIdentity(claims)
 	}
-	return rh.Header, soteErr
+	return
 }
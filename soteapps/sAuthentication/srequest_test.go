@@ -1,12 +1,8 @@
 package sAuthentication
 
 import (
-	"flag"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"reflect"
-	"strings"
 	"testing"
 	"time"
 
@@ -48,60 +44,32 @@ func validateBodyMock(data []byte, tEnvironment string) sError.SoteError {
 	return soteError
 }
 
-func TestInit(t *testing.T) {
+func TestScriptAccess(t *testing.T) {
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(sConfigParams.STAGING, issuer)
+	defer SetIdentityProvider(sConfigParams.STAGING, nil)
+	token, _ := issuer.Token(map[string]interface{}{"custom:organizations-id": "10003"})
 	soteErr := validateBodyTest([]byte(`{
+		"json-web-token": "` + token + `",
 		"aws-user-name": "soteuser",
 		"organizations-id": 10003
 	}`))
-	AssertEqual(t, soteErr.ErrCode, nil)                   //ignored by default
-	flag.Lookup("test.count").Value.(flag.Getter).Set("0") //enable unittest validations
-}
-
-func TestScriptAccessMissingFile(t *testing.T) {
-	os.Remove(DEVICE_FILE)
-	soteErr := validateBodyTest([]byte(`{
-		"aws-user-name": "soteuser",
-		"organizations-id": 10003,
-		"device-id": 123456789
-	}`))
-	AssertEqual(t, strings.Split(soteErr.FmtErrMsg, " Message return:")[0], "209010: .git/device.info file was not found.")
-}
-
-func TestScriptAccessInvalidEntry(t *testing.T) {
-	os.Remove(DEVICE_FILE)
-	ioutil.WriteFile(DEVICE_FILE, []byte("HELLO WORLD"), 0644)
-	soteErr := validateBodyTest([]byte(`{
-		"aws-user-name": "soteuser",
-		"organizations-id": 10003,
-		"device-id": 123456789
-	}`))
-	AssertEqual(t, soteErr.FmtErrMsg, "208355: Token is invalid")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
 }
 
 func TestScriptAccessTimeoutToken(t *testing.T) {
-	now := fmt.Sprint(time.Now().Unix() - DEVICE_TIMEOUT)
-	os.Remove(DEVICE_FILE)
-	ioutil.WriteFile(DEVICE_FILE, []byte(now), 0644)
+	issuer, _ := NewLocalIssuer("")
+	UseLocalIssuer(sConfigParams.STAGING, issuer)
+	defer SetIdentityProvider(sConfigParams.STAGING, nil)
+	token, _ := issuer.Token(map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()})
 	soteErr := validateBodyTest([]byte(`{
 		"request-header": {
+			"json-web-token": "` + token + `",
 			"aws-user-name": "soteuser",
-			"organizations-id": 10003,
-			"device-id": ` + now + `
+			"organizations-id": 10003
 		}
 	}`))
-	AssertEqual(t, soteErr.FmtErrMsg, "208350: Token is expired")
-}
-
-func TestScriptAccess(t *testing.T) {
-	now := fmt.Sprint(time.Now().Unix())
-	os.Remove(DEVICE_FILE)
-	ioutil.WriteFile(DEVICE_FILE, []byte(now), 0644)
-	soteErr := validateBodyTest([]byte(`{
-		"aws-user-name": "soteuser",
-		"organizations-id": 10003,
-		"device-id": ` + now + `
-	}`))
-	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, soteErr.ErrCode, 208350)
 }
 
 func TestRequestMissingAwsUserName(t *testing.T) {
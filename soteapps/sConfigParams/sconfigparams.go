@@ -43,8 +43,10 @@ const (
 	DBNAMEKEY               = "DB_NAME"
 	DBPASSWORDKEY           = "DATABASE_PASSWORD"
 	DBPORTKEY               = "DB_PORT"
+	DBREPLICAHOSTSKEY       = "DB_REPLICA_HOSTS"
 	DBSSLMODEKEY            = "DB_SSL_MODE"
 	DBUSERKEY               = "DB_USERNAME"
+	RATELIMITSKEY           = "RATE_LIMITS"
 	URL                     = "url"
 	TLSURLMASK              = "tls-urlmask"
 	UNPROCESSEDDOCUMENTSKEY = "inbound/name"
@@ -256,6 +258,52 @@ func GetDBName(application, environment string) (dbName string, soteErr sError.S
 	return
 }
 
+/*
+GetDBReplicaHosts will retrieve the comma separated read replica host parameter that is in AWS System Manager service for
+the ROOTPATH and application.  Application and environment are required.
+*/
+func GetDBReplicaHosts(application, environment string) (dbReplicaHosts []string, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+
+	var tDBReplicaHosts interface{}
+
+	if soteErr = ValidateApplication(application); soteErr.ErrCode == nil {
+		if soteErr = ValidateEnvironment(environment); soteErr.ErrCode == nil {
+			tDBReplicaHosts, soteErr = getParameter(application, strings.ToLower(environment), DBREPLICAHOSTSKEY)
+			if tDBReplicaHosts != nil {
+				for _, host := range strings.Split(tDBReplicaHosts.(string), ",") {
+					if host = strings.TrimSpace(host); host != "" {
+						dbReplicaHosts = append(dbReplicaHosts, host)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+/*
+GetRateLimits will retrieve the rate limits parameter (JSON list of limits, see sHelper.LoadRateLimits) that is in AWS
+System Manager service for the ROOTPATH and application.  Application and environment are required.
+*/
+func GetRateLimits(application, environment string) (rateLimits string, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+
+	var tRateLimits interface{}
+
+	if soteErr = ValidateApplication(application); soteErr.ErrCode == nil {
+		if soteErr = ValidateEnvironment(environment); soteErr.ErrCode == nil {
+			tRateLimits, soteErr = getParameter(application, strings.ToLower(environment), RATELIMITSKEY)
+			if tRateLimits != nil {
+				rateLimits = tRateLimits.(string)
+			}
+		}
+	}
+
+	return
+}
+
 /*
 GetDBSSLMode will retrieve the database SSL mode parameter that is in AWS System Manager service for the ROOTPATH and
 application.  Application and environment are required.
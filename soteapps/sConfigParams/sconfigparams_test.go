@@ -103,6 +103,22 @@ func TestGetDBHost(tPtr *testing.T) {
 		tPtr.Errorf("GetDBHost failed: Expected soteErr to be 200513: %v", soteErr.FmtErrMsg)
 	}
 }
+func TestGetDBReplicaHosts(tPtr *testing.T) {
+	if _, soteErr := GetDBReplicaHosts("SCOTT", STAGING); soteErr.ErrCode != 109999 {
+		tPtr.Errorf("GetDBReplicaHosts failed: Expected soteErr to be 109999: %v", soteErr.FmtErrMsg)
+	}
+	if _, soteErr := GetDBReplicaHosts("", STAGING); soteErr.ErrCode != 200513 {
+		tPtr.Errorf("GetDBReplicaHosts failed: Expected soteErr to be 200513: %v", soteErr.FmtErrMsg)
+	}
+}
+func TestGetRateLimits(tPtr *testing.T) {
+	if _, soteErr := GetRateLimits("SCOTT", STAGING); soteErr.ErrCode != 109999 {
+		tPtr.Errorf("GetRateLimits failed: Expected soteErr to be 109999: %v", soteErr.FmtErrMsg)
+	}
+	if _, soteErr := GetRateLimits("", STAGING); soteErr.ErrCode != 200513 {
+		tPtr.Errorf("GetRateLimits failed: Expected soteErr to be 200513: %v", soteErr.FmtErrMsg)
+	}
+}
 func TestGetDBPort(tPtr *testing.T) {
 	if _, soteErr := GetDBPort(API, STAGING); soteErr.ErrCode != nil {
 		tPtr.Errorf("GetDBPort failed: Expected soteErr to be nil: %v", soteErr.FmtErrMsg)
@@ -17,6 +17,7 @@ var (
 	DBSSLMode      string
 	AppEnvironment string
 	DBPort         int
+	DBReplicaHosts []string
 )
 
 func GetAWSParams() (soteErr sError.SoteError) {
@@ -33,6 +34,12 @@ func GetAWSParams() (soteErr sError.SoteError) {
 						DBSSLMode, soteErr = sConfigParams.GetDBSSLMode(API, AppEnvironment)
 						if soteErr.ErrCode == nil {
 							DBPort, soteErr = sConfigParams.GetDBPort(API, AppEnvironment)
+							if soteErr.ErrCode == nil {
+								// Read replicas are optional, the primary is used for everything when they are not configured
+								if DBReplicaHosts, soteErr = sConfigParams.GetDBReplicaHosts(API, AppEnvironment); soteErr.ErrCode == 109999 {
+									soteErr = sError.SoteError{}
+								}
+							}
 						}
 					}
 				}
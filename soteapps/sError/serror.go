@@ -68,6 +68,7 @@ var (
 		100000: {100000, USERERROR, 1, "Item Name", ": %v already exists", EmptyMap, "", nil},
 		100100: {100100, USERERROR, 2, "List of users roles, Requested action", ": Your roles %v are not authorized to %v", EmptyMap, "", nil},
 		100200: {100200, PROCESSERROR, 0, "None", ": Row has been updated since reading it, re-read the row", EmptyMap, "", nil},
+		100300: {100300, USERERROR, 1, "Requested action", ": Too many requests to %v, retry later", EmptyMap, "", nil},
 		100500: {100500, PROCESSERROR, 1, "Thing being changed", ": You are making changes to a canceled or completed %v", EmptyMap, "", nil},
 		100600: {100600, PROCESSERROR, 1, "Item is not active", ": You are making changes to an inactive %v", EmptyMap, "", nil},
 		101010: {101010, PROCESSERROR, 1, "Service Name", ": %v timed out", EmptyMap, "", nil},
@@ -152,10 +153,14 @@ var (
 		208330: {208330, PERMISSIONERROR, 0, "None", ": client id is not valid", EmptyMap, "", nil},
 		208340: {208340, PERMISSIONERROR, 0, "None", ": client id is not valid for this application", EmptyMap, "", nil},
 		208350: {208350, PERMISSIONERROR, 0, "None", ": Token is expired", EmptyMap, "", nil},
+		208351: {208351, PERMISSIONERROR, 0, "None", ": Token is not valid yet", EmptyMap, "", nil},
+		208352: {208352, PERMISSIONERROR, 0, "None", ": Token was issued in the future", EmptyMap, "", nil},
 		208355: {208355, PERMISSIONERROR, 0, "None", ": Token is invalid", EmptyMap, "", nil},
 		208356: {208356, PERMISSIONERROR, 0, "None", ": Token contains an invalid number of segments", EmptyMap, "", nil},
+		208357: {208357, PERMISSIONERROR, 0, "None", ": Token signature is invalid", EmptyMap, "", nil},
 		208360: {208360, PERMISSIONERROR, 1, "Claim names", ": These claims are invalid: %v", EmptyMap, "", nil},
 		208370: {208370, PERMISSIONERROR, 0, "None", ": Required claim(s) is/are missing", EmptyMap, "", nil},
+		208380: {208380, PERMISSIONERROR, 0, "None", ": Token was revoked", EmptyMap, "", nil},
 		209000: {209000, CONFIGURATIONISSUE, 0, "None", ": .env files are missing", EmptyMap, "", nil},
 		209010: {209010, CONFIGURATIONISSUE, 2, "File name, Message returned from Open", ": %v file was not found. Message return: %v", EmptyMap, "", nil},
 		209100: {209100, CONFIGURATIONISSUE, 1, "Environment name", ": environment variable is missing (%v)", EmptyMap, "", nil},
@@ -214,6 +219,7 @@ func (e *SoteError) Unwrap() error { return e.Err }
 		100000	Item Name > : %v already exists
 		100100	List of users roles, Requested action > : Your roles %v are not authorized to %v
 		100200	None > : Row has been updated since reading it, re-read the row
+		100300	Requested action > : Too many requests to %v, retry later
 		100500	Thing being changed > : You are making changes to a canceled or completed %v
 		100600	Item is not active > : You are making changes to an inactive %v
 		101010	Service Name > : %v timed out
@@ -278,10 +284,14 @@ func (e *SoteError) Unwrap() error { return e.Err }
 		208330	None > : client id is not valid
 		208340	None > : client id is not valid for this application
 		208350	None > : Token is expired
+		208351	None > : Token is not valid yet
+		208352	None > : Token was issued in the future
 		208355	None > : Token is invalid
 		208356	None > : Token contains an invalid number of segments
+		208357	None > : Token signature is invalid
 		208360	Claim names > : These claims are invalid: %v
 		208370	None > : Required claim(s) is/are missing
+		208380	None > : Token was revoked
 		209000	None > : .env files are missing
 		209010	File name, Message returned from Open > : %v file was not found. Message return: %v
 		209100	Environment name > : environment variable is missing (%v)
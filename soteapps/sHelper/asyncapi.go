@@ -0,0 +1,103 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	ASYNCAPIVERSION = "2.0.0"
+	REPLYCHANNEL    = "{organizations-id}.{aws-user-name}" // see Subscriber.PublishMessage
+)
+
+// AsyncAPI returns the AsyncAPI document of the subjects the service consumes and the replies it publishes
+func (r *Run) AsyncAPI(version string) (document []byte, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		replies []interface{}
+	)
+	channels := map[string]interface{}{}
+	for _, s := range r.Subscribers {
+		channels[s.Subject] = map[string]interface{}{
+			"description": fmt.Sprintf("Stream: %v, Consumer: %v", s.StreamName, s.ConsumerName),
+			"x-stream":    s.StreamName,
+			"x-consumer":  s.ConsumerName,
+			"publish": map[string]interface{}{
+				"operationId": s.ConsumerName,
+				"bindings":    map[string]interface{}{"nats": map[string]interface{}{"queue": s.ConsumerName}},
+				"message": map[string]interface{}{
+					"name":        s.ConsumerName + "-request",
+					"contentType": "application/json",
+					"payload":     s.requestSchema(),
+				},
+			},
+		}
+		replies = append(replies, map[string]interface{}{
+			"name":        s.ConsumerName + "-reply",
+			"contentType": "application/json",
+			"payload":     s.replySchema(),
+		})
+	}
+	if len(replies) > 0 {
+		channels[REPLYCHANNEL] = map[string]interface{}{
+			"description": "Replies to the requestor of the message",
+			"parameters": map[string]interface{}{
+				"organizations-id": map[string]interface{}{"schema": map[string]interface{}{"type": "integer"}},
+				"aws-user-name":    map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+			"subscribe": map[string]interface{}{
+				"message": map[string]interface{}{"oneOf": replies},
+			},
+		}
+	}
+	doc := map[string]interface{}{
+		"asyncapi":           ASYNCAPIVERSION,
+		"info":               map[string]interface{}{"title": r.Env.ApplicationName, "version": version},
+		"defaultContentType": "application/json",
+		"channels":           channels,
+	}
+	if document, err := json.MarshalIndent(doc, "", "\t"); err != nil {
+		return nil, NewError().InvalidJson("AsyncAPI")
+	} else {
+		return document, soteErr
+	}
+}
+
+// requestSchema is the published schema of the subscriber or the schema of its struct
+func (s *Subscriber) requestSchema() interface{} {
+	if s.Schema == nil {
+		return map[string]interface{}{}
+	} else if s.Schema.document != nil {
+		return json.RawMessage(s.Schema.document)
+	} else if s.Schema.StructRef != nil {
+		return JsonSchema(s.Schema.StructRef)
+	}
+	return map[string]interface{}{}
+}
+
+// replySchema describes the message published by PublishMessage, the message or the error of the request
+func (s *Subscriber) replySchema() map[string]interface{} {
+	message := map[string]interface{}{}
+	if s.Schema != nil && s.Schema.reply != nil && s.Schema.reply.document != nil {
+		json.Unmarshal(s.Schema.reply.document, &message)
+		delete(message, "$schema")
+	} else if s.Schema != nil && s.Schema.ReplyRef != nil {
+		message = JsonSchema(s.Schema.ReplyRef)
+		delete(message, "$schema")
+	}
+	soteError := JsonSchema(sError.SoteError{})
+	delete(soteError, "$schema")
+	return map[string]interface{}{
+		"$schema":  JSONSCHEMAVERSION,
+		"type":     "object",
+		"required": []string{"message-id"},
+		"properties": map[string]interface{}{
+			"message-id": map[string]interface{}{"type": "string"},
+			"message":    message,
+			"error":      soteError,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAsyncAPI(t *testing.T) {
+	type TestReply struct {
+		Id int `json:"id"`
+	}
+	s := newSubscriber()
+	s.Schema = &Schema{
+		StructRef: &TestSchema{},
+		ReplyRef:  &TestReply{},
+	}
+	s.Run.AddSubscriber(s, nil)
+	s.Run.AddSubscriber(NewSubscriber(s.Run, "other-consumer", "other-subject", "other-stream"), nil)
+
+	data, soteErr := s.Run.AsyncAPI("1.0.0")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	var document struct {
+		AsyncAPI string `json:"asyncapi"`
+		Info     struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Channels map[string]map[string]interface{} `json:"channels"`
+	}
+	AssertEqual(t, json.Unmarshal(data, &document), nil)
+	AssertEqual(t, document.AsyncAPI, ASYNCAPIVERSION)
+	AssertEqual(t, document.Info.Title, s.Run.Env.ApplicationName)
+	AssertEqual(t, document.Info.Version, "1.0.0")
+	AssertEqual(t, len(document.Channels), 3)
+
+	channel := document.Channels["test-subject"]
+	AssertEqual(t, channel["x-stream"], BSLSTREAMNAME)
+	AssertEqual(t, channel["x-consumer"], "test-consumer")
+	message := channel["publish"].(map[string]interface{})["message"].(map[string]interface{})
+	payload := message["payload"].(map[string]interface{})
+	AssertEqual(t, payload["properties"].(map[string]interface{})["field1"].(map[string]interface{})["type"], "string")
+	AssertEqual(t, document.Channels["other-subject"]["x-stream"], "other-stream")
+
+	replies := document.Channels[REPLYCHANNEL]["subscribe"].(map[string]interface{})["message"].(map[string]interface{})["oneOf"].([]interface{})
+	AssertEqual(t, len(replies), 2)
+	reply := replies[0].(map[string]interface{})["payload"].(map[string]interface{})["properties"].(map[string]interface{})
+	AssertEqual(t, reply["message"].(map[string]interface{})["properties"].(map[string]interface{})["id"].(map[string]interface{})["type"], "integer")
+	AssertEqual(t, reply["error"].(map[string]interface{})["type"], "object")
+}
+
+func TestAsyncAPIDocument(t *testing.T) {
+	s := newSubscriber()
+	s.Schema = &Schema{document: []byte(`{"title": "published"}`)}
+	s.Run.AddSubscriber(s, nil)
+	data, _ := s.Run.AsyncAPI("1.0.0")
+	var document map[string]interface{}
+	json.Unmarshal(data, &document)
+	channel := document["channels"].(map[string]interface{})["test-subject"].(map[string]interface{})
+	payload := channel["publish"].(map[string]interface{})["message"].(map[string]interface{})["payload"].(map[string]interface{})
+	AssertEqual(t, payload["title"], "published")
+}
@@ -0,0 +1,165 @@
+package sHelper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	AUDITTABLE      = "sote.audittrail" // see db/migration/audittrail.sql
+	DELETEDATCOLUMN = "deleted_at"
+	DELETEDBYCOLUMN = "deleted_by"
+)
+
+var (
+	softDeleteTables = map[string]bool{}
+	auditTables      = map[string]string{} // schema.table -> primary key
+)
+
+// AddSoftDeleteTable declares a table with the deleted_at and deleted_by columns.
+// Delete sets the columns instead of removing the rows and Select, Update and Delete skip the deleted rows.
+func AddSoftDeleteTable(table string) {
+	sLogger.DebugMethod()
+	softDeleteTables[qualifiedTable(table)] = true
+}
+
+func RemoveSoftDeleteTable(table string) {
+	delete(softDeleteTables, qualifiedTable(table))
+}
+
+// AddAuditTable declares a table whose Insert, Update and Delete are recorded into the audit table
+// with the before/after row images, the aws-user-name and message-id of the requestor.
+// The rows of an Upsert are recorded as INSERT or UPDATE (with the image of the overwritten row).
+func AddAuditTable(table, primaryKey string) {
+	sLogger.DebugMethod()
+	auditTables[qualifiedTable(table)] = primaryKey
+}
+
+func RemoveAuditTable(table string) {
+	delete(auditTables, qualifiedTable(table))
+}
+
+// Requestor sets the request header used by the audit trail and soft delete (TenantScope sets it as well).
+func (q Query) Requestor(header RequestHeaderSchema) Query {
+	q.header = &header
+	return q
+}
+
+// WithDeleted includes the soft deleted rows.
+func (q Query) WithDeleted() Query {
+	q.withDeleted = true
+	return q
+}
+
+// HardDelete removes the rows of a soft delete table.
+func (q Query) HardDelete() Query {
+	q.hardDelete = true
+	return q
+}
+
+func (q *Query) softDelete() {
+	if !softDeleteTables[getTable(q)] || q.hardDelete {
+		return
+	} else if q.header == nil {
+		q.soteErr = NewError().MustBePopulated(fmt.Sprintf("Requestor (%v)", getTable(q)))
+		return
+	}
+	q.Values = append(q.Values, q.header.AwsUserName)
+	q.Sql = bytes.NewBufferString(fmt.Sprintf("UPDATE %v SET %v = now(), %v = $%v", getTable(q), DELETEDATCOLUMN, DELETEDBYCOLUMN, len(q.Values)))
+	if q.RowVersion != nil {
+		q.Sql.WriteString(fmt.Sprintf(", %v = %v", q.RowVersion.Column, q.RowVersion.next(q.RowVersion.Column)))
+	}
+	q.action = "UPDATE"
+	q.auditAction = "DELETE"
+}
+
+func (q *Query) audited() bool {
+	_, ok := auditTables[getTable(q)]
+	return ok && (q.action == "INSERT" || q.action == "UPDATE" || q.action == "DELETE")
+}
+
+// auditCTEs wraps the statement (RETURNING *) into a CTE and adds the insert of its row images into the audit table
+func (q *Query) auditCTEs(sql string) (ctes []string) {
+	if !q.audited() {
+		return
+	} else if q.header == nil {
+		q.soteErr = NewError().MustBePopulated(fmt.Sprintf("Requestor (%v)", getTable(q)))
+		return
+	}
+	action := "'" + q.auditAction + "'"
+	if q.auditAction == "" {
+		action = "'" + q.action + "'"
+	}
+	pk := auditTables[getTable(q)]
+	before, after, from := "NULL", "to_jsonb(c)", "changed c"
+	switch q.action {
+	case "UPDATE":
+		switch {
+		case q.Join != "": // only the rows of the table, once per row
+			ctes = append(ctes, fmt.Sprintf("before AS (SELECT * FROM %v WHERE %v IN (SELECT %v.%v FROM %v%v))", getTable(q), pk,
+				getTable(q), pk, q.updateFrom(), prefixed(" WHERE ", q.Where)))
+		default:
+			ctes = append(ctes, fmt.Sprintf("before AS (SELECT * FROM %v%v)", getTable(q), prefixed(" WHERE ", q.Where)))
+		}
+	case "INSERT":
+		if where := q.conflictWhere(); where != "" { // the rows overwritten by an Upsert
+			ctes = append(ctes, fmt.Sprintf("before AS (SELECT * FROM %v WHERE %v)", getTable(q), where))
+			action = fmt.Sprintf("CASE WHEN b.%v IS NULL THEN 'INSERT' ELSE 'UPDATE' END", pk)
+		}
+	case "DELETE":
+		before, after = "to_jsonb(c)", "NULL"
+	}
+	if len(ctes) > 0 {
+		before = "to_jsonb(b)"
+		from += fmt.Sprintf(" LEFT JOIN before b ON b.%v = c.%v", pk, pk)
+	}
+	q.Values = append(q.Values, q.header.AwsUserName, q.header.MessageId)
+	return append(ctes, fmt.Sprintf("changed AS (%v)", sql),
+		fmt.Sprintf("audit AS (INSERT INTO %v (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+			"SELECT '%v', %v, %v, %v, $%v::text, $%v::text FROM %v)", AUDITTABLE, getTable(q), action, before, after, len(q.Values)-1, len(q.Values), from))
+}
+
+// conflictWhere selects the rows conflicting with the rows of an Upsert, the conflict columns must be in Columns
+func (q *Query) conflictWhere() string {
+	var (
+		indexes []int
+		rows    []string
+	)
+	if q.auditAction != "UPSERT" || len(q.Columns) == 0 || len(q.conflict) == 0 {
+		return ""
+	}
+	for _, name := range q.conflict {
+		index := -1
+		for i, column := range q.Columns {
+			if column == name {
+				index = i
+			}
+		}
+		if index < 0 {
+			return ""
+		}
+		indexes = append(indexes, index)
+	}
+	total := len(q.Rows)
+	if total == 0 {
+		total = 1
+	}
+	for i := 0; i < total; i++ {
+		conditions := make([]string, len(indexes))
+		for j, index := range indexes {
+			conditions[j] = fmt.Sprintf("%v = $%v", q.conflict[j], i*len(q.Columns)+index+1)
+		}
+		rows = append(rows, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return strings.Join(rows, " OR ")
+}
+
+func prefixed(prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	return prefix + value
+}
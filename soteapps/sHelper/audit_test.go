@@ -0,0 +1,122 @@
+package sHelper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+)
+
+var (
+	auditHeader = RequestHeaderSchema{AwsUserName: "soteuser", MessageId: "123", OrganizationId: 10000}
+)
+
+func TestAuditSoftDelete(t *testing.T) {
+	AddSoftDeleteTable("TABLE1")
+	defer RemoveSoftDeleteTable("TABLE1")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+
+	query := Query{Table: "TABLE1", Where: "ID=1"}.Requestor(auditHeader).Delete("ID")
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "UPDATE sote.TABLE1 SET deleted_at = now(), deleted_by = $1 WHERE (ID=1) AND deleted_at IS NULL RETURNING ID")
+
+	query = Query{Table: "TABLE1", Where: "ID=1"}.HardDelete().Delete()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "DELETE FROM sote.TABLE1 WHERE ID=1")
+
+	_, soteErr = Query{Table: "TABLE1", Where: "ID=1"}.Delete().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200513: Requestor (sote.TABLE1) must be populated")
+}
+
+func TestAuditSoftDeleteSelect(t *testing.T) {
+	AddSoftDeleteTable("TABLE1")
+	AddSoftDeleteTable("TABLE2")
+	defer RemoveSoftDeleteTable("TABLE1")
+	defer RemoveSoftDeleteTable("TABLE2")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+
+	query := Query{Table: "TABLE1", Alias: "a"}.InnerJoin("TABLE2", "b", "b.ID = a.ID").Select()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT * FROM sote.TABLE1 a INNER JOIN sote.TABLE2 b ON (b.ID = a.ID) AND b.deleted_at IS NULL WHERE a.deleted_at IS NULL")
+
+	query = Query{Table: "TABLE1"}.WithDeleted().Select()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT * FROM sote.TABLE1")
+}
+
+func TestAuditInsert(t *testing.T) {
+	AddAuditTable("TABLE1", "ID")
+	defer RemoveAuditTable("TABLE1")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, fmt.Sprintf("%v", args), "[Hello soteuser 123]")
+		return nil, nil
+	}
+	query := Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}}.Requestor(auditHeader).Insert("ID")
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "WITH changed AS (INSERT INTO sote.TABLE1 (COL1) VALUES($1) RETURNING *), "+
+		"audit AS (INSERT INTO sote.audittrail (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+		"SELECT 'sote.TABLE1', 'INSERT', NULL, to_jsonb(c), $2::text, $3::text FROM changed c) SELECT ID FROM changed")
+}
+
+func TestAuditUpdate(t *testing.T) {
+	AddAuditTable("TABLE1", "ID")
+	defer RemoveAuditTable("TABLE1")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}, Where: "ID=1"}.Requestor(auditHeader).Update()
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "WITH before AS (SELECT * FROM sote.TABLE1 WHERE ID=1), changed AS (UPDATE sote.TABLE1 SET COL1 = $1 WHERE ID=1 RETURNING *), "+
+		"audit AS (INSERT INTO sote.audittrail (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+		"SELECT 'sote.TABLE1', 'UPDATE', to_jsonb(b), to_jsonb(c), $2::text, $3::text FROM changed c LEFT JOIN before b ON b.ID = c.ID) SELECT * FROM changed")
+
+	_, soteErr = Query{Table: "TABLE1", Where: "ID=1"}.HardDelete().Delete().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200513: Requestor (sote.TABLE1) must be populated")
+}
+
+func TestAuditUpdateJoin(t *testing.T) {
+	AddAuditTable("TABLE1", "ID")
+	defer RemoveAuditTable("TABLE1")
+	query := Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}, Join: "FROM sote.TABLE2 t2",
+		Where: "t2.ID = TABLE1.CID AND t2.COL2 = 1"}.Requestor(auditHeader).Update()
+	sql, _ := query.build()
+	// the before images are the rows of the table selected through the join
+	AssertEqual(t, sql, "WITH before AS (SELECT * FROM sote.TABLE1 WHERE ID IN (SELECT sote.TABLE1.ID FROM sote.TABLE1, sote.TABLE2 t2 "+
+		"WHERE t2.ID = TABLE1.CID AND t2.COL2 = 1)), changed AS (UPDATE sote.TABLE1 SET COL1 = $1 FROM sote.TABLE2 t2 "+
+		"WHERE t2.ID = TABLE1.CID AND t2.COL2 = 1 RETURNING *), "+
+		"audit AS (INSERT INTO sote.audittrail (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+		"SELECT 'sote.TABLE1', 'UPDATE', to_jsonb(b), to_jsonb(c), $2::text, $3::text FROM changed c LEFT JOIN before b ON b.ID = c.ID) SELECT * FROM changed")
+}
+
+func TestAuditUpsert(t *testing.T) {
+	AddAuditTable("TABLE1", "ID")
+	defer RemoveAuditTable("TABLE1")
+	query := Query{Table: "TABLE1", Columns: []string{"ID", "COL1"}, Rows: [][]interface{}{{1, "Hello"}, {2, "World"}}}.
+		Requestor(auditHeader).Upsert([]string{"ID"})
+	sql, _ := query.build()
+	// an overwritten row is audited as an UPDATE with its before image
+	AssertEqual(t, sql, "WITH before AS (SELECT * FROM sote.TABLE1 WHERE (ID = $1) OR (ID = $3)), "+
+		"changed AS (INSERT INTO sote.TABLE1 (ID, COL1) VALUES($1, $2), ($3, $4) ON CONFLICT (ID) DO UPDATE SET COL1 = EXCLUDED.COL1 RETURNING *), "+
+		"audit AS (INSERT INTO sote.audittrail (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+		"SELECT 'sote.TABLE1', CASE WHEN b.ID IS NULL THEN 'INSERT' ELSE 'UPDATE' END, to_jsonb(b), to_jsonb(c), $5::text, $6::text "+
+		"FROM changed c LEFT JOIN before b ON b.ID = c.ID) SELECT * FROM changed")
+}
+
+func TestAuditSoftDeleteAudit(t *testing.T) {
+	AddAuditTable("TABLE1", "ID")
+	AddSoftDeleteTable("TABLE1")
+	defer RemoveAuditTable("TABLE1")
+	defer RemoveSoftDeleteTable("TABLE1")
+	query := Query{Table: "TABLE1", Where: "ID=1"}.Requestor(auditHeader).Delete()
+	sql, _ := query.build()
+	AssertEqual(t, sql, "WITH before AS (SELECT * FROM sote.TABLE1 WHERE (ID=1) AND deleted_at IS NULL), "+
+		"changed AS (UPDATE sote.TABLE1 SET deleted_at = now(), deleted_by = $1 WHERE (ID=1) AND deleted_at IS NULL RETURNING *), "+
+		"audit AS (INSERT INTO sote.audittrail (audit_table, audit_action, before_image, after_image, aws_user_name, message_id) "+
+		"SELECT 'sote.TABLE1', 'DELETE', to_jsonb(b), to_jsonb(c), $2::text, $3::text FROM changed c LEFT JOIN before b ON b.ID = c.ID) SELECT * FROM changed")
+}
@@ -2,9 +2,16 @@ package sHelper
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"gitlab.com/soteapps/packages/v2021/sDatabase"
 	"gitlab.com/soteapps/packages/v2021/sError"
 	"gitlab.com/soteapps/packages/v2021/sLogger"
@@ -18,20 +25,55 @@ type Query struct {
 	Schema        string
 	Columns       []string
 	Values        []interface{}
+	Rows          [][]interface{}
+	RowVersion    *RowVersion
+	Alias         string
+	ColumnMap     map[string]string
+	Joins         []JoinTable
 	Join          string
 	Where         string
 	OrderBy       string
 	GroupBy       string
 	Limit         *int64
 	Offset        *int64
+	Timeout       time.Duration // statement timeout, Run.Database.Timeout is used when it is not set
 	action        string
 	returnColumns []string
+	soteErr       sError.SoteError
+	tenant        *int
+	allTenants    bool
+	header        *RequestHeaderSchema
+	withDeleted   bool
+	hardDelete    bool
+	auditAction   string
+	primary       bool
+	with          []subQuery
+	exists        []subQuery
+	rowWhere      string   // WHERE of an UPDATE without the RowVersion condition
+	conflict      []string // conflict columns of an Upsert
 }
 
 type DatabaseHelper struct {
 	dbConnInfo sDatabase.ConnInfo
 	run        *Run
-	query      func(sql string, args ...interface{}) (sDatabase.SRows, error)
+	query      func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error)
+	copyFrom   func(ctx context.Context, tableName pgx.Identifier, columns []string, rows [][]interface{}) (int64, error)
+	sendBatch  func(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+	replicas   []*replicaHelper // read replicas used by Select, mutations and batches always use the primary
+	next       int
+	mu         sync.Mutex
+}
+
+// RowVersion is the column used for the optimistic concurrency control of the rows.
+// Insert and Update maintain the column, Update only changes the row when the column still has the Value read by the client.
+type RowVersion struct {
+	Column    string
+	Value     interface{}
+	Timestamp bool // the column is a timestamp set to now() instead of an incremented counter
+}
+
+type Batch struct {
+	Queries []Query
 }
 
 type QueryResult struct {
@@ -40,9 +82,13 @@ type QueryResult struct {
 }
 
 type Pagination struct {
-	Total  int64 `json:"total"`
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	Total     int64  `json:"total"`
+	Limit     int64  `json:"limit"`
+	Offset    int64  `json:"offset"`
+	Next      string `json:"next,omitempty"`
+	Previous  string `json:"previous,omitempty"`
+	Estimated bool   `json:"estimated,omitempty"`
+	keyset    *keyset
 }
 
 func NewDatabase(run *Run) (soteErr sError.SoteError) {
@@ -56,10 +102,17 @@ func NewDatabase(run *Run) (soteErr sError.SoteError) {
 			run.dbHelper = &DatabaseHelper{
 				run:        run,
 				dbConnInfo: dbConnInfo,
-				query: func(sql string, args ...interface{}) (sDatabase.SRows, error) {
-					return dbConnInfo.DBPoolPtr.Query(run.dbHelper.dbConnInfo.DBContext, sql, args...)
+				query: func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+					return dbConnInfo.DBPoolPtr.Query(ctx, sql, args...)
+				},
+				copyFrom: func(ctx context.Context, tableName pgx.Identifier, columns []string, rows [][]interface{}) (int64, error) {
+					return dbConnInfo.DBPoolPtr.CopyFrom(ctx, tableName, columns, pgx.CopyFromRows(rows))
+				},
+				sendBatch: func(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+					return dbConnInfo.DBPoolPtr.SendBatch(ctx, batch)
 				},
 			}
+			run.dbHelper.replicas = newReplicas(run, sDatabase.DBReplicaHosts)
 		}
 	}
 	return
@@ -72,14 +125,53 @@ func (q Query) Pagination() Query {
 
 func (q Query) Exec(r *Run) (sDatabase.SRows, sError.SoteError) {
 	sLogger.DebugMethod()
+	sql, soteErr := q.build()
+	if soteErr.ErrCode != nil {
+		return nil, soteErr
+	}
+	if soteErr = q.estimate(r); soteErr.ErrCode != nil {
+		return nil, soteErr
+	}
+	tRows, err := r.statement("Exec", q.timeout(r), q.readOnly(), sql, q.Values...)
+	if err == nil {
+		tRows = q.versionRows(r, tRows)
+	}
+	return tRows, q.GetError(err)
+}
+
+func (q *Query) build() (string, sError.SoteError) {
+	if q.soteErr.ErrCode != nil {
+		return "", q.soteErr
+	}
 	if q.action == "SELECT" {
-		q.Sql.WriteString(" FROM " + getTable(&q))
-	} else if q.action == "INSERT" || q.action == "UPDATE" {
+		from := q.from()
+		q.existsWhere()
+		if q.Result.Pagination != nil && q.Result.Pagination.keyset != nil {
+			q.keysetBuild(from)
+		}
+		q.Sql.WriteString(" FROM " + from)
+	} else if q.action == "UPDATE" || q.action == "DELETE" {
+		q.existsWhere()
+	} else if q.action == "INSERT" && len(q.Rows) > 0 {
+		for _, row := range q.Rows {
+			if len(row) != len(q.Rows[0]) || (len(q.Columns) > 0 && len(q.Columns) != len(row)) {
+				return "", NewError().SqlError("the number of columns in the query does not match the number of values")
+			}
+		}
+	} else if q.action == "INSERT" {
 		if len(q.Columns) > 0 && len(q.Columns) != len(q.Values) {
-			return nil, NewError().SqlError("the number of columns in the query does not match the number of values")
+			return "", NewError().SqlError("the number of columns in the query does not match the number of values")
+		}
+	}
+	if q.action == "UPDATE" && q.RowVersion != nil {
+		if q.RowVersion.Value == nil {
+			return "", NewError().MustBePopulated("RowVersion.Value")
 		}
+		q.rowWhere = q.Where
+		q.Values = append(q.Values, q.RowVersion.Value)
+		q.andWhere(fmt.Sprintf("%v = $%v", q.RowVersion.Column, len(q.Values)))
 	}
-	if q.Join != "" {
+	if q.Join != "" && q.action != "SELECT" {
 		q.Sql.WriteString(" " + q.Join)
 	}
 	if q.Where != "" {
@@ -97,42 +189,72 @@ func (q Query) Exec(r *Run) (sDatabase.SRows, sError.SoteError) {
 	if q.Offset != nil {
 		q.Sql.WriteString(fmt.Sprintf(" OFFSET %v", *q.Offset))
 	}
-	if len(q.returnColumns) > 0 {
+	if q.audited() {
+		q.Sql.WriteString(" RETURNING *") // the audit images, see withPrefix
+	} else if len(q.returnColumns) > 0 {
 		q.Sql.WriteString(" RETURNING " + strings.Join(q.returnColumns, ", "))
 	}
-	sql := q.Sql.String()
-	sLogger.Info("Database::Exec - " + sql)
-	tRows, err := r.dbHelper.query(sql, q.Values...)
-	return tRows, q.GetError(err)
+	q.withPrefix()
+	if q.soteErr.ErrCode != nil {
+		return "", q.soteErr
+	}
+	return q.Sql.String(), sError.SoteError{}
 }
 
-func getTable(q *Query) string {
+func getSchema(q *Query) string {
 	if q.Schema == "" {
-		return "sote." + q.Table
-	} else {
-		return fmt.Sprintf("%v.%v", q.Schema, q.Table)
+		return "sote"
 	}
+	return q.Schema
+}
+
+func getTable(q *Query) string {
+	return fmt.Sprintf("%v.%v", getSchema(q), q.Table)
 }
 
 func values(q *Query) []string {
-	values := make([]string, len(q.Values))
-	for i := 0; i < len(q.Values); i++ {
-		values[i] = fmt.Sprintf("$%v", i+1)
+	return placeholders(1, len(q.Values))
+}
+
+func placeholders(start, total int) []string {
+	values := make([]string, total)
+	for i := 0; i < total; i++ {
+		values[i] = fmt.Sprintf("$%v", start+i)
 	}
 	return values
 }
 
+func (v *RowVersion) next(current string) string {
+	if v.Timestamp {
+		return "now()"
+	} else if current == "" {
+		return "1"
+	}
+	return current + " + 1"
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// where adds the filter-header conditions, the values are bound as placeholders in the order of the names
 func (q *Query) where(op string, obj map[string]interface{}) {
-	for name, val := range obj {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		if q.Where != "" {
 			q.Where += " AND "
 		}
-		switch val.(type) {
-		case string:
-			q.Where += fmt.Sprintf("%v %v '%v'", name, op, val)
-		default:
-			q.Where += fmt.Sprintf("%v %v %v", name, op, val)
-		}
+		q.Values = append(q.Values, obj[name])
+		q.Where += fmt.Sprintf("%v %v $%v", q.column(name), op, len(q.Values))
 	}
 }
 
@@ -141,19 +263,15 @@ func (q Query) Select() Query {
 	q.action = "SELECT"
 	q.Sql = bytes.NewBufferString("SELECT ")
 	if q.Filter != nil {
-		if q.Result.Pagination != nil {
-			q.Sql.WriteString("count(*) OVER(), ")
-		}
-		q.Sql.WriteString(strings.Join(q.Filter.Items, ", "))
-		q.GroupBy = strings.Join(q.Filter.GroupBy, ", ")
+		q.GroupBy = strings.Join(q.columns(q.Filter.GroupBy), ", ")
 		if len(q.Filter.SortAsc) > 0 {
-			q.OrderBy = strings.Join(q.Filter.SortAsc, ", ") + " ASC"
+			q.OrderBy = strings.Join(q.columns(q.Filter.SortAsc), ", ") + " ASC"
 		}
 		if len(q.Filter.SortDesc) > 0 {
 			if q.OrderBy != "" {
 				q.OrderBy += ", "
 			}
-			q.OrderBy += strings.Join(q.Filter.SortDesc, ", ") + " DESC"
+			q.OrderBy += strings.Join(q.columns(q.Filter.SortDesc), ", ") + " DESC"
 		}
 		if q.Filter.Limit != nil {
 			q.Limit = q.Filter.Limit
@@ -170,9 +288,18 @@ func (q Query) Select() Query {
 		q.where("=", q.Filter.Equal)
 		q.where("<", q.Filter.Less)
 		q.where(">", q.Filter.Greater)
+		q.scopeWhere()
+		if q.Result.Pagination != nil && q.Result.Pagination.keyset != nil {
+			q.keysetSelect()
+		} else if q.Result.Pagination != nil {
+			q.Sql.WriteString("count(*) OVER(), ")
+		}
+		q.Sql.WriteString(strings.Join(q.columns(q.Filter.Items), ", "))
 	} else if len(q.Columns) == 0 {
+		q.scopeWhere()
 		q.Sql.WriteString("*")
 	} else {
+		q.scopeWhere()
 		q.Sql.WriteString(strings.Join(q.Columns, ", "))
 	}
 	return q
@@ -186,12 +313,17 @@ func (q Query) Update(returnColumns ...string) Query {
 	total := len(q.Columns)
 	if total == len(q.Values) {
 		values := values(&q)
+		set := make([]string, 0, total+1)
 		for i, name := range q.Columns {
-			q.Sql.WriteString(fmt.Sprintf("%v = %v", name, values[i]))
-			if i+1 < total {
-				q.Sql.WriteString(", ")
-			}
+			set = append(set, fmt.Sprintf("%v = %v", name, values[i]))
+		}
+		if q.RowVersion != nil && !contains(q.Columns, q.RowVersion.Column) {
+			set = append(set, fmt.Sprintf("%v = %v", q.RowVersion.Column, q.RowVersion.next(q.RowVersion.Column)))
 		}
+		q.Sql.WriteString(strings.Join(set, ", "))
+		q.scopeWhere()
+	} else if total > 0 {
+		q.soteErr = NewError().SqlError("the number of columns in the query does not match the number of values")
 	}
 	return q
 }
@@ -200,24 +332,113 @@ func (q Query) Insert(returnColumns ...string) Query {
 	sLogger.DebugMethod()
 	q.action = "INSERT"
 	q.returnColumns = returnColumns
+	q.scopeInsert()
 	q.Sql = bytes.NewBufferString("INSERT INTO " + getTable(&q))
+	version := ""
 	if len(q.Columns) > 0 {
-		q.Sql.WriteString(fmt.Sprintf(" (%v)", strings.Join(q.Columns, ", ")))
+		columns := q.Columns
+		if q.RowVersion != nil && !contains(q.Columns, q.RowVersion.Column) {
+			columns = append(append([]string{}, q.Columns...), q.RowVersion.Column)
+			version = ", " + q.RowVersion.next("")
+		}
+		q.Sql.WriteString(fmt.Sprintf(" (%v)", strings.Join(columns, ", ")))
+	}
+	if len(q.Rows) > 0 {
+		// multi-row insert, the Values are flattened in the order of the placeholders
+		tuples := make([]string, len(q.Rows))
+		q.Values = []interface{}{}
+		for i, row := range q.Rows {
+			tuples[i] = fmt.Sprintf("(%v%v)", strings.Join(placeholders(len(q.Values)+1, len(row)), ", "), version)
+			q.Values = append(q.Values, row...)
+		}
+		q.Sql.WriteString(" VALUES" + strings.Join(tuples, ", "))
+	} else {
+		q.Sql.WriteString(fmt.Sprintf(" VALUES(%v%v)", strings.Join(values(&q), ", "), version))
+	}
+	return q
+}
+
+// Upsert inserts the row(s), on a conflict with conflictColumns the remaining columns are updated with the new values
+func (q Query) Upsert(conflictColumns []string, returnColumns ...string) Query {
+	sLogger.DebugMethod()
+	q = q.Insert(returnColumns...)
+	q.auditAction, q.conflict = "UPSERT", conflictColumns
+	updates := []string{}
+	for _, name := range q.Columns {
+		if !contains(conflictColumns, name) {
+			updates = append(updates, fmt.Sprintf("%v = EXCLUDED.%v", name, name))
+		}
+	}
+	if len(updates) > 0 && q.RowVersion != nil && !contains(q.Columns, q.RowVersion.Column) {
+		updates = append(updates, fmt.Sprintf("%v = %v", q.RowVersion.Column, q.RowVersion.next(q.Table+"."+q.RowVersion.Column)))
+	}
+	if len(updates) == 0 {
+		q.Sql.WriteString(fmt.Sprintf(" ON CONFLICT (%v) DO NOTHING", strings.Join(conflictColumns, ", ")))
+	} else {
+		q.Sql.WriteString(fmt.Sprintf(" ON CONFLICT (%v) DO UPDATE SET %v", strings.Join(conflictColumns, ", "), strings.Join(updates, ", ")))
+		if column, ok := q.tenantColumn(); ok { // never take over the row of another organization
+			q.Sql.WriteString(fmt.Sprintf(" WHERE %v.%v = EXCLUDED.%v", q.Table, column, column))
+		}
 	}
-	q.Sql.WriteString(fmt.Sprintf(" VALUES(%v)", strings.Join(values(&q), ", ")))
 	return q
 }
 
+// InsertIfNotExists inserts the row(s) and skips the ones conflicting with conflictColumns (any constraint when empty)
+func (q Query) InsertIfNotExists(conflictColumns []string, returnColumns ...string) Query {
+	sLogger.DebugMethod()
+	q = q.Insert(returnColumns...)
+	if len(conflictColumns) > 0 {
+		q.Sql.WriteString(fmt.Sprintf(" ON CONFLICT (%v) DO NOTHING", strings.Join(conflictColumns, ", ")))
+	} else {
+		q.Sql.WriteString(" ON CONFLICT DO NOTHING")
+	}
+	return q
+}
+
+// CopyFrom bulk loads the Rows using the postgres COPY protocol and returns the number of rows copied
+func (q Query) CopyFrom(r *Run) (int64, sError.SoteError) {
+	sLogger.DebugMethod()
+	if q.scopeInsert(); q.soteErr.ErrCode != nil {
+		return 0, q.soteErr
+	} else if _, ok := auditTables[getTable(&q)]; ok {
+		return 0, NewError().SqlError(fmt.Sprintf("COPY is not supported by the audited table %v", getTable(&q)))
+	}
+	if len(q.Columns) == 0 {
+		return 0, NewError().MustBePopulated("Columns")
+	}
+	for _, row := range q.Rows {
+		if len(row) != len(q.Columns) {
+			return 0, NewError().SqlError("the number of columns in the query does not match the number of values")
+		}
+	}
+	config := r.databaseConfig()
+	sql := fmt.Sprintf("COPY %v (%v) Rows: %v", getTable(&q), strings.Join(q.Columns, ", "), len(q.Rows))
+	config.log("CopyFrom", sql, nil)
+	ctx, cancel := r.context(q.timeout(r))
+	defer cancel()
+	start := time.Now()
+	total, err := r.dbHelper.copyFrom(ctx, pgx.Identifier{getSchema(&q), q.Table}, q.Columns, q.Rows)
+	if msg := config.slow(sql, time.Since(start), func() pgconn.CommandTag { return pgconn.CommandTag(fmt.Sprintf("COPY %v", total)) }); msg != "" {
+		sLogger.Info(msg)
+	}
+	return total, q.GetError(err)
+}
+
 func (q Query) Delete(returnColumns ...string) Query {
 	sLogger.DebugMethod()
 	q.action = "DELETE"
 	q.returnColumns = returnColumns
 	q.Sql = bytes.NewBufferString("DELETE FROM " + getTable(&q))
+	q.withDeleted = q.withDeleted || q.hardDelete
+	q.scopeWhere()
+	q.softDelete()
 	return q
 }
 
 func (q Query) GetError(err error) (soteErr sError.SoteError) {
-	if err != nil {
+	if timedOut(err) {
+		soteErr = NewError().TimedOut(fmt.Sprintf("Query (%v)", getTable(&q)))
+	} else if err != nil {
 		soteErr = NewError().SqlError(fmt.Sprint(err))
 	}
 	return
@@ -228,34 +449,151 @@ func (q *Query) Scan(tRows sDatabase.SRows) (tCols []interface{}, soteErr sError
 		err error
 	)
 	tCols, err = tRows.Values()
-	if err == nil {
+	if err == nil && q.Result.Pagination != nil && q.Result.Pagination.keyset != nil {
+		q.keysetScan(tCols)
+	} else if err == nil {
 		offset := 0
 		if q.Result.Pagination != nil {
 			offset = 1
 			q.Result.Pagination.Total = tCols[0].(int64)
 		}
-		row := make(map[string]interface{})
-		names := q.Columns
-		if q.Filter != nil {
-			names = q.Filter.Items
-		}
-		for i := offset; i < len(tCols) && i < len(names)+offset; i++ { //0 - total
-			name := names[i-offset]
-			row[name] = tCols[i]
-		}
-		q.Result.Items = append(q.Result.Items, row)
+		q.Result.Items = append(q.Result.Items, q.row(tCols, offset))
 	} else {
 		soteErr = NewError().SqlError(err.Error())
 	}
 	return
 }
 
+func (q *Query) row(tCols []interface{}, offset int) map[string]interface{} {
+	row := make(map[string]interface{})
+	names := q.Columns
+	if q.Filter != nil {
+		names = q.Filter.Items
+	}
+	for i := offset; i < len(tCols) && i < len(names)+offset; i++ { //0 - total
+		name := names[i-offset]
+		row[name] = tCols[i]
+	}
+	return row
+}
+
 func (q Query) Close(tRows sDatabase.SRows, soteErr *sError.SoteError) {
 	tRows.Close()
 	if soteErr == nil || soteErr.ErrCode == nil {
 		err := tRows.Err()
 		if err != nil {
-			*soteErr = NewError().SqlError(err.Error())
+			*soteErr = q.GetError(err)
+		} else if q.action == "UPDATE" && q.RowVersion != nil && tRows.CommandTag().RowsAffected() == 0 {
+			*soteErr = NewError().RowUpdated() // the row was changed by another request
+			if versionRows, ok := tRows.(*rowVersionRows); ok {
+				if exists, err := versionRows.exists(); err != nil {
+					*soteErr = q.GetError(err)
+				} else if !exists {
+					*soteErr = NewError().ItemNotFound(getTable(&q))
+				}
+			}
+		}
+	}
+}
+
+// rowVersionRows are the rows of an UPDATE with RowVersion, exists tells a removed row from a row changed by another request
+type rowVersionRows struct {
+	sDatabase.SRows
+	exists func() (bool, error)
+}
+
+// versionRows wraps the rows of an UPDATE with RowVersion, Close reports a removed row as not found
+func (q *Query) versionRows(r *Run, tRows sDatabase.SRows) sDatabase.SRows {
+	if tRows == nil || q.action != "UPDATE" || q.RowVersion == nil {
+		return tRows
+	}
+	return &rowVersionRows{SRows: tRows, exists: func() (bool, error) { return q.rowExists(r) }}
+}
+
+// updateFrom is the FROM of a SELECT of the rows of an UPDATE, UPDATE table ... FROM t is SELECT ... FROM table, t
+func (q *Query) updateFrom() string {
+	if q.Join == "" {
+		return getTable(q)
+	}
+	return getTable(q) + strings.Replace(" "+q.Join, " FROM ", ", ", 1)
+}
+
+// rowExists selects the rows of the UPDATE without the RowVersion condition on the primary, only the values referenced by
+// the WHERE are sent
+func (q *Query) rowExists(r *Run) (bool, error) {
+	var (
+		args    []interface{}
+		numbers = map[string]string{}
+	)
+	where := placeholder.ReplaceAllStringFunc(q.rowWhere, func(p string) string {
+		if _, found := numbers[p]; !found {
+			n, _ := strconv.Atoi(p[1:])
+			args = append(args, q.Values[n-1])
+			numbers[p] = fmt.Sprintf("$%v", len(args))
+		}
+		return numbers[p]
+	})
+	sql := "SELECT 1 FROM " + q.updateFrom()
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	tRows, err := r.statement("Exists", q.timeout(r), false, sql+" LIMIT 1", args...)
+	if err != nil {
+		return false, err
+	}
+	defer tRows.Close()
+	exists := tRows.Next()
+	return exists, tRows.Err()
+}
+
+// Exec sends all queries to the database in one round trip, scan is called for the result of every query in order
+func (b Batch) Exec(r *Run, scan func(index int, q *Query, tRows sDatabase.SRows) sError.SoteError) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		sql     string
+		timeout time.Duration
+		total   int64
+	)
+	config := r.databaseConfig()
+	batch := &pgx.Batch{}
+	for i := range b.Queries {
+		if sql, soteErr = b.Queries[i].build(); soteErr.ErrCode != nil {
+			return
+		}
+		config.log(fmt.Sprintf("Batch[%v]", i), sql, b.Queries[i].Values)
+		batch.Queue(sql, b.Queries[i].Values...)
+		if t := b.Queries[i].timeout(r); t > timeout {
+			timeout = t // the batch is one round trip, the longest timeout applies
+		}
+	}
+	ctx, cancel := r.context(timeout)
+	defer cancel()
+	start := time.Now()
+	results := r.dbHelper.sendBatch(ctx, batch)
+	for i := range b.Queries {
+		tRows, err := results.Query()
+		if err != nil {
+			soteErr = b.Queries[i].GetError(err)
+			break
+		}
+		tRows = b.Queries[i].versionRows(r, tRows)
+		if scan != nil {
+			soteErr = scan(i, &b.Queries[i], tRows)
+		}
+		b.Queries[i].Close(tRows, &soteErr)
+		if config.SlowQuery > 0 {
+			total += tRows.CommandTag().RowsAffected()
+		}
+		if soteErr.ErrCode != nil {
+			break
 		}
 	}
+	if err := results.Close(); err != nil && soteErr.ErrCode == nil {
+		soteErr = NewError().SqlError(err.Error())
+	}
+	sql = fmt.Sprintf("Batch of %v statements", len(b.Queries))
+	if msg := config.slow(sql, time.Since(start), func() pgconn.CommandTag { return pgconn.CommandTag(fmt.Sprintf("BATCH %v", total)) }); msg != "" {
+		sLogger.Info(msg)
+	}
+	return
 }
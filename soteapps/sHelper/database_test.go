@@ -1,14 +1,17 @@
 package sHelper
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"regexp"
-
+	"strings"
 	"testing"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"gitlab.com/soteapps/packages/v2021/sDatabase"
 	"gitlab.com/soteapps/packages/v2021/sError"
 )
@@ -65,12 +68,25 @@ func createDatabaseHelper(r *Run, result *Result) sError.SoteError {
 	if r.dbHelper == nil {
 		r.dbHelper = &DatabaseHelper{}
 	}
-	r.dbHelper.query = func(sql string, args ...interface{}) (sDatabase.SRows, error) {
+	r.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
 		return result.rows, result.err
 	}
 	return soteErr
 }
 
+type BatchResults struct {
+	pgx.BatchResults
+	rows  []sDatabase.SRows
+	index int
+}
+
+func (b *BatchResults) Query() (pgx.Rows, error) {
+	b.index++
+	return b.rows[b.index-1], nil
+}
+
+func (b *BatchResults) Close() error { return nil }
+
 type Result struct {
 	rows sDatabase.SRows
 	err  error
@@ -146,7 +162,8 @@ func TestDatabasePaginationExec(t *testing.T) {
 		Filter: &pagination.Filter,
 	}.Pagination().Select()
 	query.Exec(run)
-	AssertEqual(t, query.Sql.String(), "SELECT count(*) OVER(), COL1, COL2, COL3 FROM sote.TABLE1 WHERE COL1 = 'Hello World' AND COL3 < 30 AND COL2 > 20 GROUP BY COL2, COL3 ORDER BY COL1 ASC, COL2 DESC LIMIT 1 OFFSET 2")
+	AssertEqual(t, query.Sql.String(), "SELECT count(*) OVER(), COL1, COL2, COL3 FROM sote.TABLE1 WHERE COL1 = $1 AND COL3 < $2 AND COL2 > $3 GROUP BY COL2, COL3 ORDER BY COL1 ASC, COL2 DESC LIMIT 1 OFFSET 2")
+	AssertEqual(t, fmt.Sprint(query.Values), "[Hello World 30 20]")
 }
 
 func TestDatabaseExecFullQuery(t *testing.T) {
@@ -287,3 +304,235 @@ func TestDatabaseQueryPanic(t *testing.T) {
 		Table: "TABLE",
 	}.Select().Exec(run)
 }
+
+func TestDatabaseInsertRows(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{
+		Table:   "TABLE1",
+		Columns: []string{"COL1", "COL2"},
+		Rows:    [][]interface{}{{"Hello", true}, {"World", false}},
+	}.Insert("COL1")
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 (COL1, COL2) VALUES($1, $2), ($3, $4) RETURNING COL1")
+	AssertEqual(t, fmt.Sprint(query.Values), "[Hello true World false]")
+}
+
+func TestDatabaseInsertRowsError(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	_, soteErr := Query{
+		Table:   "TABLE1",
+		Columns: []string{"COL1", "COL2"},
+		Rows:    [][]interface{}{{"Hello", true}, {"World"}},
+	}.Insert().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200999: SQL error - see Details ERROR DETAILS: >>Key: SQL ERROR Value: the number of columns in the query does not match the number of values")
+}
+
+func TestDatabaseUpsert(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{
+		Table:   "TABLE1",
+		Columns: []string{"ID", "COL1", "COL2"},
+		Values:  []interface{}{1, "Hello", true},
+	}.Upsert([]string{"ID"}, "ID")
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 (ID, COL1, COL2) VALUES($1, $2, $3) ON CONFLICT (ID) DO UPDATE SET COL1 = EXCLUDED.COL1, COL2 = EXCLUDED.COL2 RETURNING ID")
+}
+
+func TestDatabaseInsertIfNotExists(t *testing.T) {
+	query := Query{
+		Table:   "TABLE1",
+		Columns: []string{"ID", "COL1"},
+		Rows:    [][]interface{}{{1, "Hello"}, {2, "World"}},
+	}.InsertIfNotExists([]string{"ID"})
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 (ID, COL1) VALUES($1, $2), ($3, $4) ON CONFLICT (ID) DO NOTHING")
+	query = Query{
+		Table:  "TABLE1",
+		Values: []interface{}{1},
+	}.InsertIfNotExists(nil)
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 VALUES($1) ON CONFLICT DO NOTHING")
+}
+
+func TestDatabaseCopyFrom(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.copyFrom = func(ctx context.Context, tableName pgx.Identifier, columns []string, rows [][]interface{}) (int64, error) {
+		AssertEqual(t, tableName.Sanitize(), `"myschema"."TABLE1"`)
+		AssertEqual(t, fmt.Sprint(columns), "[COL1 COL2]")
+		return int64(len(rows)), nil
+	}
+	total, soteErr := Query{
+		Table:   "TABLE1",
+		Schema:  "myschema",
+		Columns: []string{"COL1", "COL2"},
+		Rows:    [][]interface{}{{"Hello", true}, {"World", false}},
+	}.CopyFrom(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, total, int64(2))
+
+	_, soteErr = Query{Table: "TABLE1", Rows: [][]interface{}{{"Hello"}}}.CopyFrom(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200513: Columns must be populated")
+}
+
+func TestDatabaseBatch(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.sendBatch = func(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+		AssertEqual(t, batch.Len(), 2)
+		return &BatchResults{rows: []sDatabase.SRows{NoErrorRow{}, NoErrorRow{}}}
+	}
+	batch := Batch{
+		Queries: []Query{
+			Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}}.Insert("ID"),
+			Query{Table: "TABLE2", Where: "ID=1"}.Delete(),
+		},
+	}
+	scanned := []int{}
+	soteErr := batch.Exec(run, func(index int, q *Query, tRows sDatabase.SRows) sError.SoteError {
+		scanned = append(scanned, index)
+		return sError.SoteError{}
+	})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, fmt.Sprint(scanned), "[0 1]")
+	AssertEqual(t, batch.Queries[0].Sql.String(), "INSERT INTO sote.TABLE1 (COL1) VALUES($1) RETURNING ID")
+	AssertEqual(t, batch.Queries[1].Sql.String(), "DELETE FROM sote.TABLE2 WHERE ID=1")
+}
+
+func TestDatabaseBatchRowVersionNotFound(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, sql, "SELECT 1 FROM sote.TABLE1 WHERE ID=1 LIMIT 1")
+		return sDatabase.Rows{IEerr: func() error { return nil }, INext: func() bool { return false }}, nil
+	}
+	run.dbHelper.sendBatch = func(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+		return &BatchResults{rows: []sDatabase.SRows{sDatabase.Rows{
+			IEerr:       func() error { return nil },
+			ICommandTag: func() pgconn.CommandTag { return pgconn.CommandTag("UPDATE 0") },
+		}}}
+	}
+	batch := Batch{
+		Queries: []Query{
+			Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}, Where: "ID=1",
+				RowVersion: &RowVersion{Column: "ROW_VERSION", Value: 5}}.Update(),
+		},
+	}
+	// the row was removed, not changed by another request
+	AssertEqual(t, batch.Exec(run, nil).ErrCode, 109999)
+}
+
+func TestDatabaseUpdateRowVersion(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{
+		Table:      "TABLE1",
+		Columns:    []string{"COL1"},
+		Values:     []interface{}{"Hello"},
+		Where:      "ID=1",
+		RowVersion: &RowVersion{Column: "ROW_VERSION", Value: 5},
+	}.Update()
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "UPDATE sote.TABLE1 SET COL1 = $1, ROW_VERSION = ROW_VERSION + 1 WHERE (ID=1) AND ROW_VERSION = $2")
+
+	query = Query{
+		Table:      "TABLE1",
+		Columns:    []string{"COL1"},
+		Values:     []interface{}{"Hello"},
+		RowVersion: &RowVersion{Column: "UPDATED_AT", Timestamp: true},
+	}.Update()
+	_, soteErr = query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200513: RowVersion.Value must be populated")
+
+	// only the row version is set
+	query = Query{Table: "TABLE1", Where: "ID=1", RowVersion: &RowVersion{Column: "ROW_VERSION", Value: 5}}.Update()
+	_, soteErr = query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "UPDATE sote.TABLE1 SET ROW_VERSION = ROW_VERSION + 1 WHERE (ID=1) AND ROW_VERSION = $1")
+}
+
+func TestDatabaseInsertRowVersion(t *testing.T) {
+	query := Query{
+		Table:      "TABLE1",
+		Columns:    []string{"ID", "COL1"},
+		Rows:       [][]interface{}{{1, "Hello"}, {2, "World"}},
+		RowVersion: &RowVersion{Column: "ROW_VERSION"},
+	}.Insert()
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 (ID, COL1, ROW_VERSION) VALUES($1, $2, 1), ($3, $4, 1)")
+	query = Query{
+		Table:      "TABLE1",
+		Columns:    []string{"ID", "COL1"},
+		Values:     []interface{}{1, "Hello"},
+		RowVersion: &RowVersion{Column: "UPDATED_AT", Timestamp: true},
+	}.Upsert([]string{"ID"})
+	AssertEqual(t, query.Sql.String(), "INSERT INTO sote.TABLE1 (ID, COL1, UPDATED_AT) VALUES($1, $2, now()) ON CONFLICT (ID) DO UPDATE SET COL1 = EXCLUDED.COL1, UPDATED_AT = now()")
+}
+
+func TestDatabaseCloseRowVersion(t *testing.T) {
+	var affected int64
+	tRows := sDatabase.Rows{
+		IEerr:       func() error { return nil },
+		ICommandTag: func() pgconn.CommandTag { return pgconn.CommandTag(fmt.Sprintf("UPDATE %v", affected)) },
+	}
+	query := Query{
+		Table:      "TABLE1",
+		Columns:    []string{"COL1"},
+		Values:     []interface{}{"Hello"},
+		RowVersion: &RowVersion{Column: "ROW_VERSION", Value: 5},
+	}.Update()
+	soteErr := sError.SoteError{}
+	query.Close(tRows, &soteErr)
+	AssertEqual(t, soteErr.FmtErrMsg, "100200: Row has been updated since reading it, re-read the row")
+
+	affected = 1
+	soteErr = sError.SoteError{}
+	query.Close(tRows, &soteErr)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+}
+
+func TestDatabaseCloseRowVersionNotFound(t *testing.T) {
+	var (
+		existsSql  string
+		existsArgs []interface{}
+		found      bool
+	)
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		if strings.HasPrefix(sql, "SELECT 1") {
+			existsSql, existsArgs = sql, args
+			next := found
+			return sDatabase.Rows{
+				IEerr: func() error { return nil },
+				INext: func() bool { defer func() { next = false }(); return next },
+			}, nil
+		}
+		return sDatabase.Rows{
+			IEerr:       func() error { return nil },
+			ICommandTag: func() pgconn.CommandTag { return pgconn.CommandTag("UPDATE 0") },
+		}, nil
+	}
+	query := Query{
+		Table:      "TABLE1",
+		Columns:    []string{"COL1"},
+		Values:     []interface{}{"Hello"},
+		Where:      "ID = $2",
+		RowVersion: &RowVersion{Column: "ROW_VERSION", Value: 5},
+	}.Update()
+	query.Values = append(query.Values, 1)
+	tRows, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	query.Close(tRows, &soteErr)
+	AssertEqual(t, soteErr.ErrCode, 109999)
+	AssertEqual(t, existsSql, "SELECT 1 FROM sote.TABLE1 WHERE ID = $1 LIMIT 1")
+	AssertEqual(t, fmt.Sprint(existsArgs), "[1]")
+
+	found = true
+	tRows, soteErr = query.Exec(run)
+	query.Close(tRows, &soteErr)
+	AssertEqual(t, soteErr.ErrCode, 100200)
+}
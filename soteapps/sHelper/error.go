@@ -28,7 +28,19 @@ func (err sErrorHelper) ItemNotFound(itemName interface{}) sError.SoteError {
 	return err.factory(109999, itemName) //"109999: %v was/were not found"
 }
 
+func (err sErrorHelper) RowUpdated() sError.SoteError {
+	return err.factory(100200) //"100200: Row has been updated since reading it, re-read the row"
+}
+
+func (err sErrorHelper) TooManyRequests(subject string) sError.SoteError {
+	return err.factory(100300, subject) //"100300: Too many requests to %v, retry later"
+}
+
 // Process_Error's
+func (err sErrorHelper) TimedOut(serviceName string) sError.SoteError {
+	return err.factory(101010, serviceName) //"101010: %v timed out"
+}
+
 func (err sErrorHelper) SqlError(error string) sError.SoteError {
 	err.errorDetails = map[string]string{"SQL ERROR": error}
 	return err.factory(200999) //"200999: SQL error - see Details"
@@ -64,6 +76,26 @@ func (err sErrorHelper) InvalidEmailAddress(fieldName string, value interface{})
 	return err.factory(207050, value, fieldName) //"207050: %v (%v) is not a valid email address"
 }
 
+func (err sErrorHelper) InvalidCharacters(fieldName string, value interface{}) sError.SoteError {
+	return err.factory(207060, value, fieldName) //"207060: %v (%v) contains special characters which are not allowed"
+}
+
+func (err sErrorHelper) InvalidDate(fieldName string, value interface{}) sError.SoteError {
+	return err.factory(207070, value, fieldName) //"207070: %v (%v) is not a valid date"
+}
+
+func (err sErrorHelper) InvalidTimestamp(fieldName string, value interface{}) sError.SoteError {
+	return err.factory(207080, value, fieldName) //"207080: %v (%v) is not a valid timestamp. Format's are UTC, GMT or Zulu"
+}
+
+func (err sErrorHelper) InvalidSize(fieldName string, value interface{}, size, limit string, expected, actual int) sError.SoteError {
+	return err.factory(207090, value, fieldName, size, limit, expected, actual) //"207090: %v (%v) is too %v. %v size: %v Actual size: %v"
+}
+
+func (err sErrorHelper) OutOfRange(fieldName string, value interface{}, greater, less interface{}) sError.SoteError {
+	return err.factory(207095, value, fieldName, greater, less) //"207095: %v (%v) must be greater than %v and less than %v"
+}
+
 // NATS_Error's
 func (err sErrorHelper) InvalidParameters(params ...interface{}) sError.SoteError {
 	return err.factory(206200, params...) //"206200: Message doesn't match signature. Sender must provide the following parameter names: %v"
@@ -32,6 +32,9 @@ func TestErrorCodes(t *testing.T) {
 	// Any new error functions for code coverage should be defined here.
 	verifyError(t, NewError().AlreadyExists("Item"), 100000, sError.USERERROR, "100000: Item already exists")
 	verifyError(t, NewError().ItemNotFound("Item"), 109999, sError.USERERROR, "109999: Item was/were not found")
+	verifyError(t, NewError().RowUpdated(), 100200, sError.PROCESSERROR, "100200: Row has been updated since reading it, re-read the row")
+	verifyError(t, NewError().TooManyRequests("bsl.trip.add"), 100300, sError.USERERROR, "100300: Too many requests to bsl.trip.add, retry later")
+	verifyError(t, NewError().TimedOut("Query"), 101010, sError.PROCESSERROR, "101010: Query timed out")
 	verifyError(t, NewError().SqlError("Connection failed"), 200999, sError.PROCESSERROR,
 		"200999: SQL error - see Details ERROR DETAILS: >>Key: SQL ERROR Value: Connection failed")
 	verifyError(t, NewError().AllowValues("a", "b", []int{1, 2, 3}), 200250, sError.PROCESSERROR, "200250: a (b) must contain one of these values: [1 2 3]")
@@ -39,6 +42,12 @@ func TestErrorCodes(t *testing.T) {
 	verifyError(t, NewError().MustBeType("a, b, c", []int{1, 2, 3}), 200200, sError.PROCESSERROR, "200200: a, b, c must be of type [1 2 3]")
 	verifyError(t, NewError().InvalidJson("./schema.json"), 207110, sError.CONTENTERROR, "207110: ./schema.json couldn't be parsed - Invalid JSON error")
 	verifyError(t, NewError().InvalidEmailAddress("To", "email@sote123.com"), 207050, sError.CONTENTERROR, "207050: email@sote123.com (To) is not a valid email address")
+	verifyError(t, NewError().InvalidCharacters("Code", "a-b"), 207060, sError.CONTENTERROR, "207060: a-b (Code) contains special characters which are not allowed")
+	verifyError(t, NewError().InvalidDate("Date", "2021-13-01"), 207070, sError.CONTENTERROR, "207070: 2021-13-01 (Date) is not a valid date")
+	verifyError(t, NewError().InvalidTimestamp("Created", "today"), 207080, sError.CONTENTERROR,
+		"207080: today (Created) is not a valid timestamp. Format's are UTC, GMT or Zulu")
+	verifyError(t, NewError().InvalidSize("Name", "ab", "small", "Min", 3, 2), 207090, sError.CONTENTERROR, "207090: ab (Name) is too small. Min size: 3 Actual size: 2")
+	verifyError(t, NewError().OutOfRange("Age", 200, 0, 150), 207095, sError.CONTENTERROR, "207095: 200 (Age) must be greater than 0 and less than 150")
 	verifyError(t, NewError().InvalidParameters("Item"), 206200, sError.NATSERROR,
 		"206200: Message doesn't match signature. Sender must provide the following parameter names: Item")
 	verifyError(t, NewError().NoDbConnection(), 209299, sError.CONFIGURATIONISSUE, "209299: No database connection has been established")
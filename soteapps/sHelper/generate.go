@@ -0,0 +1,185 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	REQUESTHEADER = "request-header"
+	FILTERHEADER  = "filter-header"
+)
+
+var (
+	// definitions shared by all the messages, they are already part of sHelper
+	headerTypes = map[string]string{
+		REQUESTHEADER: "sHelper.RequestHeaderSchema",
+		FILTERHEADER:  "sHelper.FilterHeaderSchema",
+	}
+	headerFields = map[string]string{
+		REQUESTHEADER: "Header",
+		FILTERHEADER:  "Filter",
+	}
+	scalarTypes = map[string]string{
+		"string":  "string",
+		"integer": "int64",
+		"number":  "float64",
+		"boolean": "bool",
+	}
+)
+
+// structGenerator writes the Go structs of a message schema, nested objects and definitions become their own types
+type structGenerator struct {
+	fileName    string
+	definitions map[string]*jsonProperty
+	names       map[string]bool
+	types       []string
+	imports     bool
+	soteErr     sError.SoteError
+}
+
+// GenerateStructs returns the formatted Go source of the struct typeName (and the types it uses) for the message schema
+func GenerateStructs(fileName, packageName, typeName string) (source []byte, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		data   []byte
+		schema jsonSchema
+	)
+	if data, soteErr = readSchema(fileName); soteErr.ErrCode != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, NewError().InvalidJson(fileName)
+	}
+	g := &structGenerator{fileName: fileName, definitions: schema.Definitions, names: map[string]bool{typeName: true}}
+	root := g.structType(typeName, &jsonProperty{Type: "object", Required: schema.Required, Properties: schema.Properties})
+	if g.soteErr.ErrCode != nil {
+		return nil, g.soteErr
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by schemagen from %v. DO NOT EDIT.\n\npackage %v\n\n", fileName, packageName)
+	if g.imports {
+		b.WriteString("import \"gitlab.com/soteapps/packages/v2021/sHelper\"\n\n")
+	}
+	b.WriteString(strings.Join(append([]string{root}, g.types...), "\n"))
+	if source, err := format.Source([]byte(b.String())); err != nil {
+		return nil, NewError(map[string]string{"FORMAT": err.Error()}).InternalError()
+	} else {
+		return source, soteErr
+	}
+}
+
+// readSchema reads a local schema file or resolves the URL of the schema
+func readSchema(fileName string) ([]byte, sError.SoteError) {
+	u, _ := url.Parse(fileName)
+	if u.Scheme != "" && u.Scheme != "file" {
+		return schemas.read(fileName)
+	}
+	absPath, _ := filepath.Abs(strings.TrimPrefix(fileName, "file://"))
+	data, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, NewError().FileNotFound(fileName, absPath)
+	}
+	return data, sError.SoteError{}
+}
+
+func (g *structGenerator) structType(name string, prop *jsonProperty) string {
+	var b strings.Builder
+	names := make([]string, 0, len(prop.Properties))
+	for n := range prop.Properties {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&b, "type %v struct {\n", name)
+	for _, n := range names {
+		field := prop.Properties[n]
+		fieldName := goName(n)
+		if headerField, ok := headerFields[n]; ok {
+			fieldName = headerField
+		}
+		goType := g.goType(name+fieldName, field)
+		if _, ok := scalarTypes[field.Type]; ok && field.Ref == "" && (field.Default != nil || !contains(prop.Required, n)) {
+			goType = "*" + goType // optional values and values with a default are nil when they are not in the message
+		}
+		fmt.Fprintf(&b, "\t%v %v `json:\"%v\"`", fieldName, goType, n)
+		if field.Default != nil {
+			fmt.Fprintf(&b, " // default: %#v", field.Default)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *structGenerator) goType(name string, prop *jsonProperty) string {
+	if prop.Ref != "" {
+		defName := prop.Ref[strings.LastIndex(prop.Ref, "/")+1:]
+		if t, ok := headerTypes[defName]; ok {
+			g.imports = true
+			return t
+		}
+		return g.named(goName(defName), g.definition(defName, prop.Ref))
+	}
+	if t, ok := scalarTypes[prop.Type]; ok {
+		return t
+	}
+	switch prop.Type {
+	case "array":
+		if prop.Items != nil {
+			return "[]" + g.goType(name+"Item", prop.Items)
+		}
+		return "[]interface{}"
+	case "object":
+		if len(prop.Properties) > 0 {
+			return g.named(name, prop)
+		}
+	}
+	return "interface{}"
+}
+
+// named adds the struct type once, a definition used by several fields is shared
+func (g *structGenerator) named(name string, prop *jsonProperty) string {
+	if prop != nil && !g.names[name] {
+		g.names[name] = true
+		g.types = append(g.types, g.structType(name, prop))
+	}
+	return name
+}
+
+// definition resolves a $ref to the definitions of the schema or of another schema file
+func (g *structGenerator) definition(name, ref string) *jsonProperty {
+	if strings.HasPrefix(ref, "#/definitions/") {
+		if def := g.definitions[name]; def != nil {
+			return def
+		}
+	} else {
+		var schema jsonSchema
+		data, soteErr := readSchema(ref)
+		if soteErr.ErrCode == nil && json.Unmarshal(data, &schema) == nil && schema.Definitions[name] != nil {
+			return schema.Definitions[name]
+		} else if soteErr.ErrCode != nil {
+			g.soteErr = soteErr
+			return nil
+		}
+	}
+	g.soteErr = NewError().ItemNotFound(fmt.Sprintf("%v (%v)", ref, g.fileName))
+	return nil
+}
+
+// goName turns a json name like client-company-id into ClientCompanyId
+func goName(jsonName string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(jsonName, func(r rune) bool { return r == '-' || r == '_' || r == ' ' || r == '.' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
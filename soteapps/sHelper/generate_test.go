@@ -0,0 +1,74 @@
+package sHelper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "schemagen")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "fin-trans-trip-add.json")
+	ioutil.WriteFile(fileName, []byte(`{
+		"required": ["request-header", "trip-id", "lines"],
+		"properties": {
+			"request-header": {"$ref": "#/definitions/request-header"},
+			"trip-id": {"$id": "#/properties/trip-id", "type": "integer"},
+			"memo": {"$id": "#/properties/memo", "type": "string"},
+			"currency": {"$id": "#/properties/currency", "type": "string", "default": "USD"},
+			"lines": {"$id": "#/properties/lines", "type": "array", "items": {"$ref": "#/definitions/line"}},
+			"tags": {"$id": "#/properties/tags", "type": "array", "items": {"type": "string"}},
+			"load": {"$id": "#/properties/load", "type": "object", "required": ["load-name"], "properties": {
+				"load-name": {"$id": "#/properties/load/properties/load-name", "type": "string"}
+			}},
+			"extra": {"$id": "#/properties/extra", "type": "object"}
+		},
+		"definitions": {
+			"request-header": {"type": "object"},
+			"line": {"type": "object", "required": ["amount"], "properties": {"amount": {"type": "number"}, "cost-is-unexpected": {"type": "boolean"}}}
+		}
+	}`), 0644)
+
+	source, soteErr := GenerateStructs(fileName, "packages", "FintransAdd")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, string(source), `// Code generated by schemagen from `+fileName+`. DO NOT EDIT.
+
+package packages
+
+import "gitlab.com/soteapps/packages/v2021/sHelper"
+
+type FintransAdd struct {
+	Currency *string                     `+"`json:\"currency\"`"+` // default: "USD"
+	Extra    interface{}                 `+"`json:\"extra\"`"+`
+	Lines    []Line                      `+"`json:\"lines\"`"+`
+	Load     FintransAddLoad             `+"`json:\"load\"`"+`
+	Memo     *string                     `+"`json:\"memo\"`"+`
+	Header   sHelper.RequestHeaderSchema `+"`json:\"request-header\"`"+`
+	Tags     []string                    `+"`json:\"tags\"`"+`
+	TripId   int64                       `+"`json:\"trip-id\"`"+`
+}
+
+type Line struct {
+	Amount           float64 `+"`json:\"amount\"`"+`
+	CostIsUnexpected *bool   `+"`json:\"cost-is-unexpected\"`"+`
+}
+
+type FintransAddLoad struct {
+	LoadName string `+"`json:\"load-name\"`"+`
+}
+`)
+}
+
+func TestGenerateStructsMissingDefinition(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "schemagen")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "schema.json")
+	ioutil.WriteFile(fileName, []byte(`{"properties": {"line": {"$ref": "#/definitions/line"}}}`), 0644)
+	_, soteErr := GenerateStructs(fileName, "packages", "Message")
+	AssertEqual(t, soteErr.FmtErrMsg, "109999: #/definitions/line ("+fileName+") was/were not found")
+
+	_, soteErr = GenerateStructs(filepath.Join(dir, "missing.json"), "packages", "Message")
+	AssertEqual(t, soteErr.ErrCode, 209010)
+}
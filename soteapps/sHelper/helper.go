@@ -17,6 +17,7 @@ type Helper struct {
 	InitApp          func() sError.SoteError
 	AddSubscriber    func(consumerName, subject string, listener MessageListener, schema *Schema, streamName ...string) sError.SoteError
 	Run              func(isGoroutine bool)
+	AsyncAPI         func(version string) ([]byte, sError.SoteError)
 }
 
 func NewHelper(env Environment) *Helper {
@@ -31,6 +32,7 @@ func NewHelper(env Environment) *Helper {
 		InitApp:          h.initApp,
 		AddSubscriber:    h.addSubscriber,
 		Run:              h.run,
+		AsyncAPI:         h.asyncAPI,
 	}
 	return &h
 }
@@ -66,7 +68,9 @@ func (h *Helper) run(isGoroutine bool) {
 			for _, message := range messages {
 				sLogger.DebugMethod()
 				s.Start(&message)
-				if isGoroutine {
+				if s.negotiate(&message).ErrCode != nil || s.authorize(&message).ErrCode != nil || s.rateLimit(&message).ErrCode != nil {
+					s.End(&message, sError.SoteError{}) // the requestor got the error in the reply
+				} else if isGoroutine {
 					go func(s *Subscriber, msg Msg) {
 						soteErr := s.Listener(s, &msg)
 						s.End(&msg, soteErr)
@@ -81,6 +85,11 @@ func (h *Helper) run(isGoroutine bool) {
 	})
 }
 
+func (h *Helper) asyncAPI(version string) ([]byte, sError.SoteError) {
+	sLogger.DebugMethod()
+	return h.r.AsyncAPI(version)
+}
+
 func (h *Helper) createSubscriber(consumerName, subject string, streamName ...string) *Subscriber {
 	sLogger.DebugMethod()
 	return NewSubscriber(h.r, consumerName, subject, streamName...)
@@ -138,3 +138,46 @@ func TestHelperInitApp(t *testing.T) {
 		AssertEqual(t, soteErr.FmtErrMsg, "")
 	}
 }
+
+func TestHelperRunVersion(t *testing.T) {
+	var (
+		s                 *Subscriber
+		version, received string
+		reply             sError.SoteError
+	)
+	helper := testNewHelper(t)
+	createSubscriber := helper.CreateSubscriber
+	helper.CreateSubscriber = func(consumerName, subject string, streamName ...string) *Subscriber {
+		s = createSubscriber(consumerName, subject, streamName...)
+		s.Fetch = func() ([]Msg, sError.SoteError) {
+			return []Msg{{Subject: "Test-subject", Data: []byte(`{"message-version": "` + version + `", "field1": "Hello", "request-header": ` +
+				`{"aws-user-name": "soteuser", "json-web-token": "token", "message-id": "1", "role-list": []}}`)}}, sError.SoteError{}
+		}
+		s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+			reply = soteErr
+			return sError.SoteError{}
+		}
+		return s
+	}
+	listener := func(s *Subscriber, m *Msg) sError.SoteError {
+		received = m.Schema.Version
+		return m.Schema.Parse(m.Data, &TestSchemaV1{})
+	}
+	AssertEqual(t, helper.AddSubscriber("bsl-notification-wildcard", "bsl.notification.add", listener,
+		&Schema{StructRef: &TestSchemaV2{}, Version: "2.0"}).FmtErrMsg, "")
+	AssertEqual(t, s.AddSchema(&Schema{StructRef: &TestSchemaV1{}, Version: "1.0", Deprecated: true}).FmtErrMsg, "")
+
+	// the listener gets the schema of the message version
+	version = "1.0"
+	helper.Run(false)
+	AssertEqual(t, (<-s.Run.returnChain).soteErr.FmtErrMsg, "")
+	AssertEqual(t, received, "1.0")
+	AssertEqual(t, deprecatedMessages.Get("bsl.notification.add@1.0").String(), "1")
+
+	// the requestor of an unknown version gets the error, the listener isn't called
+	version, received = "3", ""
+	helper.Run(false)
+	<-s.Run.returnChain
+	AssertEqual(t, received, "")
+	AssertEqual(t, reply.FmtErrMsg, "200250: message-version (3) must contain one of these values: [1.0 2.0]")
+}
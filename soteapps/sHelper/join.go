@@ -0,0 +1,176 @@
+package sHelper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	INNERJOIN = "INNER JOIN"
+	LEFTJOIN  = "LEFT JOIN"
+)
+
+var (
+	placeholder = regexp.MustCompile(`\$(\d+)`)
+)
+
+type JoinTable struct {
+	Kind   string
+	Table  string
+	Schema string
+	Alias  string
+	On     string
+}
+
+type subQuery struct {
+	name  string // CTE name or EXISTS/NOT EXISTS
+	query Query
+}
+
+// Column returns the column name qualified by the table alias
+func Column(alias, name string) string {
+	return alias + "." + name
+}
+
+func (q Query) InnerJoin(table, alias, on string) Query {
+	q.Joins = append(append([]JoinTable{}, q.Joins...), JoinTable{Kind: INNERJOIN, Table: table, Alias: alias, On: on})
+	return q
+}
+
+func (q Query) LeftJoin(table, alias, on string) Query {
+	q.Joins = append(append([]JoinTable{}, q.Joins...), JoinTable{Kind: LEFTJOIN, Table: table, Alias: alias, On: on})
+	return q
+}
+
+// With adds a common table expression (WITH name AS (sub)), sub must be a Select query.
+// The name can be used as the Table of a join.
+func (q Query) With(name string, sub Query) Query {
+	q.with = append(append([]subQuery{}, q.with...), subQuery{name: name, query: sub})
+	return q
+}
+
+// WhereExists adds EXISTS (sub) to the WHERE clause, sub must be a Select query and can refer to the columns of q by its Alias.
+func (q Query) WhereExists(sub Query) Query {
+	q.exists = append(append([]subQuery{}, q.exists...), subQuery{name: "EXISTS", query: sub})
+	return q
+}
+
+func (q Query) WhereNotExists(sub Query) Query {
+	q.exists = append(append([]subQuery{}, q.exists...), subQuery{name: "NOT EXISTS", query: sub})
+	return q
+}
+
+// column maps a filter-header name to the (qualified) column of the ColumnMap, only the names of the map are allowed
+func (q *Query) column(name string) string {
+	if q.ColumnMap == nil {
+		return name
+	} else if column, ok := q.ColumnMap[name]; ok {
+		return column
+	}
+	names := []string{}
+	for n := range q.ColumnMap {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	q.soteErr = NewError().AllowValues("filter-header", name, names)
+	return name
+}
+
+func (q *Query) columns(names []string) []string {
+	columns := make([]string, len(names))
+	for i, name := range names {
+		columns[i] = q.column(name)
+	}
+	return columns
+}
+
+func (q *Query) from() string {
+	from := getTable(q)
+	if q.Schema == "" && q.isCTE(q.Table) {
+		from = q.Table
+	}
+	if q.Alias != "" {
+		from += " " + q.Alias
+	}
+	if q.Join != "" {
+		from += " " + q.Join
+	}
+	for _, j := range q.Joins {
+		table := j.Table
+		if j.Schema != "" {
+			table = j.Schema + "." + j.Table
+		} else if !q.isCTE(table) {
+			table = qualifiedTable(table)
+		}
+		on := j.On
+		if column, ok := q.tenantOf(table); ok {
+			q.Values = append(q.Values, *q.tenant)
+			on = fmt.Sprintf("(%v) AND %v.%v = $%v", on, j.Alias, column, len(q.Values))
+		}
+		if softDeleteTables[table] && !q.withDeleted {
+			on = fmt.Sprintf("(%v) AND %v.%v IS NULL", on, j.Alias, DELETEDATCOLUMN)
+		}
+		from += fmt.Sprintf(" %v %v %v ON %v", j.Kind, table, j.Alias, on)
+	}
+	return from
+}
+
+func (q *Query) isCTE(name string) bool {
+	for _, w := range q.with {
+		if w.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Query) existsWhere() {
+	for _, e := range q.exists {
+		q.andWhere(fmt.Sprintf("%v (%v)", e.name, q.subQuery(e.query)))
+	}
+}
+
+func (q *Query) withPrefix() {
+	ctes := make([]string, len(q.with))
+	for i, w := range q.with {
+		ctes[i] = fmt.Sprintf("%v AS (%v)", w.name, q.subQuery(w.query))
+	}
+	sql := q.Sql.String()
+	if audit := q.auditCTEs(sql); len(audit) > 0 {
+		ctes = append(ctes, audit...)
+		sql = "SELECT * FROM changed"
+		if len(q.returnColumns) > 0 {
+			sql = "SELECT " + strings.Join(q.returnColumns, ", ") + " FROM changed"
+		}
+	}
+	if len(ctes) > 0 {
+		q.Sql.Reset()
+		q.Sql.WriteString("WITH " + strings.Join(ctes, ", ") + " " + sql)
+	}
+}
+
+// subQuery returns the SQL of sub with its placeholders renumbered after the Values of q
+func (q *Query) subQuery(sub Query) string {
+	if sub.Sql == nil {
+		sub = sub.Select()
+	}
+	sub.Sql = bytes.NewBufferString(sub.Sql.String())
+	sql, soteErr := sub.build()
+	if soteErr.ErrCode != nil {
+		q.soteErr = soteErr
+		return ""
+	}
+	offset := len(q.Values)
+	q.Values = append(q.Values, sub.Values...)
+	sLogger.Debug(fmt.Sprintf("Database::subQuery - %v", sql))
+	return placeholder.ReplaceAllStringFunc(sql, func(p string) string {
+		n, _ := strconv.Atoi(p[1:])
+		return fmt.Sprintf("$%v", n+offset)
+	})
+}
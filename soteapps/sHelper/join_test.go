@@ -0,0 +1,109 @@
+package sHelper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+)
+
+func TestJoinSelect(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{
+		Table: "trips",
+		Alias: "t",
+		Filter: &FilterHeaderSchema{
+			Items:   []string{"trip-id", "company-name"},
+			SortAsc: []string{"company-name"},
+			Equal:   map[string]interface{}{"company-name": "Sote"},
+		},
+		ColumnMap: map[string]string{
+			"trip-id":      Column("t", "trips_id"),
+			"company-name": Column("c", "name"),
+		},
+	}.InnerJoin("clientcompany", "c", "c.client_company_id = t.client_company_id").
+		LeftJoin("myschema.loads", "l", "l.trips_id = t.trips_id").Select()
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "SELECT t.trips_id, c.name FROM sote.trips t INNER JOIN sote.clientcompany c ON c.client_company_id = t.client_company_id "+
+		"LEFT JOIN myschema.loads l ON l.trips_id = t.trips_id WHERE c.name = $1 ORDER BY c.name ASC")
+	AssertEqual(t, fmt.Sprint(query.Values), "[Sote]")
+}
+
+func TestJoinColumnMapError(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	_, soteErr := Query{
+		Table:     "trips",
+		Filter:    &FilterHeaderSchema{Items: []string{"trip-id", "password"}},
+		ColumnMap: map[string]string{"trip-id": "trips_id", "memo": "memo"},
+	}.Select().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200250: filter-header (password) must contain one of these values: [memo trip-id]")
+}
+
+func TestJoinTenantScope(t *testing.T) {
+	AddTenantTable("trips")
+	AddTenantTable("clientcompany")
+	defer RemoveTenantTable("trips")
+	defer RemoveTenantTable("clientcompany")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, fmt.Sprintf("%v", args), "[10000 10000]")
+		return nil, nil
+	}
+	query := Query{
+		Table:   "trips",
+		Alias:   "t",
+		Columns: []string{"t.trips_id", "c.name"},
+	}.InnerJoin("clientcompany", "c", "c.client_company_id = t.client_company_id").TenantScope(RequestHeaderSchema{OrganizationId: 10000}).Select()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT t.trips_id, c.name FROM sote.trips t INNER JOIN sote.clientcompany c "+
+		"ON (c.client_company_id = t.client_company_id) AND c.organizations_id = $2 WHERE t.organizations_id = $1")
+}
+
+func TestJoinExistsAndWith(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, fmt.Sprintf("%v", args), "[10 EUR 2021]")
+		return nil, nil
+	}
+	recent := Query{
+		Table:   "trips",
+		Columns: []string{"trips_id"},
+		Where:   "trips_year = $1",
+		Values:  []interface{}{2021},
+	}.Select()
+	fintrans := Query{
+		Table:   "tripfinancialtransactions",
+		Alias:   "f",
+		Columns: []string{"1"},
+		Where:   "f.trips_id = r.trips_id AND f.currency_type = $1",
+		Values:  []interface{}{"EUR"},
+	}.Select()
+	query := Query{
+		Table:   "recent",
+		Alias:   "r",
+		Columns: []string{"r.trips_id"},
+		Where:   "r.trips_id > $1",
+		Values:  []interface{}{10},
+	}.With("recent", recent).WhereExists(fintrans).Select()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "WITH recent AS (SELECT trips_id FROM sote.trips WHERE trips_year = $3) SELECT r.trips_id FROM recent r "+
+		"WHERE (r.trips_id > $1) AND EXISTS (SELECT 1 FROM sote.tripfinancialtransactions f WHERE f.trips_id = r.trips_id AND f.currency_type = $2)")
+}
+
+func TestJoinNotExistsDelete(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := Query{
+		Table: "trips",
+		Where: "trips_id = $1",
+	}.WhereNotExists(Query{Table: "loads", Where: "loads.trips_id = trips.trips_id"}).Delete()
+	query.Values = []interface{}{1}
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "DELETE FROM sote.trips WHERE (trips_id = $1) AND NOT EXISTS (SELECT * FROM sote.loads WHERE loads.trips_id = trips.trips_id)")
+}
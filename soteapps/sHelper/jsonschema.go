@@ -0,0 +1,90 @@
+package sHelper
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	JSONSCHEMAVERSION = "http://json-schema.org/draft-07/schema"
+)
+
+// JsonSchema returns the JSON schema of a message struct for the services without a published schema.
+// Like Schema.Validate only the fields with a json tag are part of the message. Pointer and omitempty fields are optional,
+// numbers and booleans are optional as well because their zero value can't be told apart from a missing value.
+func JsonSchema(structRef interface{}) map[string]interface{} {
+	schema := typeSchema("#", reflect.TypeOf(structRef), map[reflect.Type]bool{})
+	schema["$schema"] = JSONSCHEMAVERSION
+	return schema
+}
+
+func typeSchema(id string, t reflect.Type, visited map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema("", t.Elem(), visited)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema("", t.Elem(), visited)}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]interface{}{"type": "string", "format": FORMATDATETIME}
+		} else if visited[t] {
+			return map[string]interface{}{"type": "object"} // recursive type
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		schema := map[string]interface{}{"type": "object"}
+		properties := map[string]interface{}{}
+		required := []string{}
+		structProperties(id, t, visited, properties, &required)
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{"type": "object"} // interface{} fields, see jsonKinds
+}
+
+// structProperties adds the fields of the struct, the fields of an injected struct are part of the struct
+func structProperties(id string, t reflect.Type, visited map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			structProperties(id, f.Type, visited, properties, required)
+			continue
+		} else if tag == "" || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		name := options[0]
+		propId := id + "/properties/" + name
+		var prop map[string]interface{}
+		if f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct {
+			prop = map[string]interface{}{"type": "object"} // Schema.Validate only follows the nested structs
+		} else {
+			prop = typeSchema(propId, f.Type, visited)
+		}
+		if id != "" {
+			prop["$id"] = propId
+		}
+		properties[name] = prop
+		if f.Type.Kind() != reflect.Ptr && !contains(options[1:], "omitempty") && (prop["type"] == "string" || prop["type"] == "array" ||
+			f.Type.Kind() == reflect.Struct) {
+			*required = append(*required, name)
+		}
+	}
+}
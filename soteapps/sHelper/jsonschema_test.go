@@ -0,0 +1,57 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TestSchemaStruct struct {
+	Header   RequestHeaderSchema `json:"request-header"`
+	Name     string              `json:"name"`
+	Count    int                 `json:"count"`
+	Note     *string             `json:"note"`
+	Tags     []string            `json:"tags,omitempty"`
+	Created  time.Time           `json:"created"`
+	internal string
+	Ignored  string
+}
+
+func TestJsonSchema(t *testing.T) {
+	schema := JsonSchema(&TestSchemaStruct{})
+	AssertEqual(t, schema["$schema"], JSONSCHEMAVERSION)
+	AssertEqual(t, schema["type"], "object")
+	AssertEqual(t, strings.Join(schema["required"].([]string), ","), "request-header,name,created")
+	properties := schema["properties"].(map[string]interface{})
+	AssertEqual(t, len(properties), 6)
+	name := properties["name"].(map[string]interface{})
+	AssertEqual(t, name["type"], "string")
+	AssertEqual(t, name["$id"], "#/properties/name")
+	AssertEqual(t, properties["count"].(map[string]interface{})["type"], "integer")
+	AssertEqual(t, properties["note"].(map[string]interface{})["type"], "string")
+	AssertEqual(t, properties["tags"].(map[string]interface{})["items"].(map[string]interface{})["type"], "string")
+	AssertEqual(t, properties["created"].(map[string]interface{})["format"], FORMATDATETIME)
+	header := properties["request-header"].(map[string]interface{})["properties"].(map[string]interface{})
+	AssertEqual(t, header["json-web-token"].(map[string]interface{})["$id"], "#/properties/request-header/properties/json-web-token")
+}
+
+func TestJsonSchemaParse(t *testing.T) {
+	type TestSchema struct {
+		Field1 string  `json:"field1"`
+		Field2 *string `json:"field2"`
+	}
+	schema := Schema{
+		StructRef: &TestSchema{},
+	}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+	var document map[string]interface{}
+	AssertEqual(t, json.Unmarshal(schema.document, &document), nil)
+	AssertEqual(t, document["$schema"], JSONSCHEMAVERSION)
+
+	body := TestSchema{}
+	AssertEqual(t, schema.Parse([]byte(`{"field1": "value"}`), &body).FmtErrMsg, "")
+	AssertEqual(t, body.Field1, "value")
+	body = TestSchema{}
+	AssertEqual(t, schema.Parse([]byte(`{"field2": "value"}`), &body).FmtErrMsg, "206200: Message doesn't match signature. Sender must provide the following parameter names: #/properties/field1")
+}
@@ -0,0 +1,352 @@
+package sHelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+const (
+	FORMATEMAIL    = "email"
+	FORMATDATE     = "date"
+	FORMATDATETIME = "date-time"
+	FORMATUUID     = "uuid"
+	VIOLATIONSKEY  = "VIOLATIONS"
+)
+
+var (
+	uuidRegex     = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+	patterns      = map[string]*regexp.Regexp{} // compiled pattern keywords of the schemas
+	patternErrors = map[string]error{}
+	patternsMu    sync.Mutex
+)
+
+// violation is one entry of the VIOLATIONS list in the ErrorDetails when Schema.AllErrors is set, Path is the JSON pointer
+// of the value in the message (e.g. /tags/1)
+type violation struct {
+	Path     string      `json:"path"`
+	Code     interface{} `json:"code"`
+	Expected string      `json:"expected"`
+	Actual   interface{} `json:"actual"`
+	Message  string      `json:"message"`
+	newError func(e sErrorHelper) sError.SoteError
+}
+
+// violations collects the errors found by Parse, only the first one is kept unless all is set
+type violations struct {
+	all  bool
+	list []violation
+}
+
+func (v *violations) add(path, expected string, actual interface{}, newError func(e sErrorHelper) sError.SoteError) {
+	if v.done() {
+		return
+	}
+	soteErr := newError(NewError())
+	v.list = append(v.list, violation{Path: path, Code: soteErr.ErrCode, Expected: expected, Actual: actual, Message: soteErr.FmtErrMsg,
+		newError: newError})
+}
+
+// done is true when the validation can stop
+func (v *violations) done() bool {
+	return !v.all && len(v.list) > 0
+}
+
+// error returns the first violation, with the list of all of them in the ErrorDetails when all is set
+func (v *violations) error() sError.SoteError {
+	if len(v.list) == 0 {
+		return sError.SoteError{}
+	} else if !v.all {
+		return v.list[0].newError(NewError())
+	}
+	data, _ := json.Marshal(v.list)
+	return v.list[0].newError(NewError(map[string]string{VIOLATIONSKEY: string(data)}))
+}
+
+// validateKeywords checks the message against the constraint keywords of the schema (minLength, pattern, format, minimum,
+// minItems, items, additionalProperties, oneOf, anyOf ...), the fields are reported with their JSON pointer in the message
+func (s *Schema) validateKeywords(data []byte, invalid *violations) {
+	var (
+		doc interface{}
+	)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		invalid.add("", "JSON", nil, func(e sErrorHelper) sError.SoteError { return e.InvalidJson("Body") })
+		return
+	}
+	root := &jsonProperty{Properties: s.jsonSchema.Properties, AdditionalProperties: s.jsonSchema.AdditionalProperties}
+	s.validateValue("", root, doc, invalid)
+}
+
+// definition returns the definition a $ref points to, definitions loaded from another file are stored by property name
+func (s *Schema) definition(name string, prop *jsonProperty) *jsonProperty {
+	if prop.Ref == "" {
+		return prop
+	}
+	if i := strings.LastIndex(prop.Ref, "/definitions/"); i >= 0 {
+		if def := s.jsonSchema.Definitions[prop.Ref[i+len("/definitions/"):]]; def != nil {
+			return def
+		}
+	}
+	if def := s.jsonSchema.Definitions[name]; def != nil {
+		return def
+	}
+	return prop
+}
+
+func (s *Schema) validateValue(path string, prop *jsonProperty, value interface{}, invalid *violations) {
+	if value == nil {
+		return
+	}
+	// the type of the properties with an $id is enforced by the struct field, "object" is also used for interface{} fields
+	if prop.Type != "" && prop.Id == "" && !isJsonType(prop.Type, value) {
+		invalid.add(path, "type "+prop.Type, value, func(e sErrorHelper) sError.SoteError { return e.MustBeType(path, prop.Type) })
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		validateString(path, prop, v, invalid)
+	case json.Number:
+		validateNumber(path, prop, v, invalid)
+	case []interface{}:
+		s.validateArray(path, prop, v, invalid)
+	case map[string]interface{}:
+		s.validateObject(path, prop, v, invalid)
+	}
+	if len(prop.AnyOf) > 0 {
+		s.validateAnyOf(path, prop.AnyOf, value, invalid)
+	}
+	if len(prop.OneOf) > 0 {
+		s.validateOneOf(path, prop.OneOf, value, invalid)
+	}
+}
+
+func validateString(path string, prop *jsonProperty, value string, invalid *violations) {
+	length := utf8.RuneCountInString(value)
+	if prop.MinLength != nil && length < *prop.MinLength {
+		invalid.add(path, fmt.Sprintf("minLength %v", *prop.MinLength), value, func(e sErrorHelper) sError.SoteError {
+			return e.InvalidSize(path, value, "small", "Min", *prop.MinLength, length)
+		})
+	}
+	if prop.MaxLength != nil && length > *prop.MaxLength {
+		invalid.add(path, fmt.Sprintf("maxLength %v", *prop.MaxLength), value, func(e sErrorHelper) sError.SoteError {
+			return e.InvalidSize(path, value, "large", "Max", *prop.MaxLength, length)
+		})
+	}
+	if prop.Pattern != "" {
+		re, err := compilePattern(prop.Pattern)
+		if err != nil {
+			invalid.add(path, "pattern "+prop.Pattern, value, func(e sErrorHelper) sError.SoteError {
+				e.errorDetails = map[string]string{"PATTERN": err.Error()}
+				return e.InternalError()
+			})
+		} else if !re.MatchString(value) {
+			invalid.add(path, "pattern "+prop.Pattern, value, func(e sErrorHelper) sError.SoteError { return e.InvalidCharacters(path, value) })
+		}
+	}
+	var newError func(e sErrorHelper) sError.SoteError
+	switch prop.Format {
+	case FORMATEMAIL:
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			newError = func(e sErrorHelper) sError.SoteError { return e.InvalidEmailAddress(path, value) }
+		}
+	case FORMATDATE:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			newError = func(e sErrorHelper) sError.SoteError { return e.InvalidDate(path, value) }
+		}
+	case FORMATDATETIME:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			newError = func(e sErrorHelper) sError.SoteError { return e.InvalidTimestamp(path, value) }
+		}
+	case FORMATUUID:
+		if !uuidRegex.MatchString(value) {
+			newError = func(e sErrorHelper) sError.SoteError { return e.InvalidCharacters(path, value) }
+		}
+	}
+	if newError != nil {
+		invalid.add(path, "format "+prop.Format, value, newError)
+	}
+}
+
+func validateNumber(path string, prop *jsonProperty, value json.Number, invalid *violations) {
+	number, _ := value.Float64()
+	greater, less := math.Inf(-1), math.Inf(1)
+	valid := true
+	if prop.Minimum != nil {
+		greater, valid = *prop.Minimum, valid && number >= *prop.Minimum
+	}
+	if prop.ExclusiveMinimum != nil {
+		greater, valid = *prop.ExclusiveMinimum, valid && number > *prop.ExclusiveMinimum
+	}
+	if prop.Maximum != nil {
+		less, valid = *prop.Maximum, valid && number <= *prop.Maximum
+	}
+	if prop.ExclusiveMaximum != nil {
+		less, valid = *prop.ExclusiveMaximum, valid && number < *prop.ExclusiveMaximum
+	}
+	if !valid {
+		invalid.add(path, fmt.Sprintf("range %v - %v", greater, less), value, func(e sErrorHelper) sError.SoteError {
+			return e.OutOfRange(path, value, greater, less)
+		})
+	}
+}
+
+func (s *Schema) validateArray(path string, prop *jsonProperty, value []interface{}, invalid *violations) {
+	if prop.MinItems != nil && len(value) < *prop.MinItems {
+		invalid.add(path, fmt.Sprintf("minItems %v", *prop.MinItems), value, func(e sErrorHelper) sError.SoteError {
+			return e.InvalidSize(path, value, "small", "Min", *prop.MinItems, len(value))
+		})
+	}
+	if prop.MaxItems != nil && len(value) > *prop.MaxItems {
+		invalid.add(path, fmt.Sprintf("maxItems %v", *prop.MaxItems), value, func(e sErrorHelper) sError.SoteError {
+			return e.InvalidSize(path, value, "large", "Max", *prop.MaxItems, len(value))
+		})
+	}
+	if prop.Items != nil {
+		items := s.definition("", prop.Items)
+		for i, item := range value {
+			if s.validateValue(fmt.Sprintf("%v/%v", path, i), items, item, invalid); invalid.done() {
+				return
+			}
+		}
+	}
+}
+
+func (s *Schema) validateObject(path string, prop *jsonProperty, value map[string]interface{}, invalid *violations) {
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	allowed, additional := additionalProperties(prop.AdditionalProperties)
+	for _, name := range names {
+		field := path + "/" + pointerToken(name)
+		if sub := prop.Properties[name]; sub != nil {
+			s.validateValue(field, s.definition(name, sub), value[name], invalid)
+		} else if !allowed {
+			known := make([]string, 0, len(prop.Properties))
+			for n := range prop.Properties {
+				known = append(known, n)
+			}
+			sort.Strings(known)
+			unknown := name
+			invalid.add(field, "additionalProperties false", value[name], func(e sErrorHelper) sError.SoteError {
+				return e.AllowValues(path, unknown, known)
+			})
+		} else if additional != nil {
+			s.validateValue(field, s.definition(name, additional), value[name], invalid)
+		}
+		if invalid.done() {
+			return
+		}
+	}
+}
+
+// validateAnyOf reports the error of the first schema when the value matches none of them
+func (s *Schema) validateAnyOf(path string, schemas []*jsonProperty, value interface{}, invalid *violations) {
+	var first *violations
+	for _, prop := range schemas {
+		branch := &violations{}
+		if s.validateValue(path, s.definition("", prop), value, branch); len(branch.list) == 0 {
+			return
+		} else if first == nil {
+			first = branch
+		}
+	}
+	v := first.list[0]
+	invalid.add(v.Path, v.Expected, v.Actual, v.newError)
+}
+
+func (s *Schema) validateOneOf(path string, schemas []*jsonProperty, value interface{}, invalid *violations) {
+	var first *violations
+	matches := 0
+	for _, prop := range schemas {
+		branch := &violations{}
+		if s.validateValue(path, s.definition("", prop), value, branch); len(branch.list) == 0 {
+			matches++
+		} else if first == nil {
+			first = branch
+		}
+	}
+	if matches > 1 {
+		invalid.add(path, "oneOf", value, func(e sErrorHelper) sError.SoteError { return e.MustBeType(path, "exactly one of the oneOf schemas") })
+	} else if matches == 0 {
+		v := first.list[0]
+		invalid.add(v.Path, v.Expected, v.Actual, v.newError)
+	}
+}
+
+// compilePattern compiles the pattern keyword once, the schemas share the compiled patterns
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	re, found := patterns[pattern]
+	if !found {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			patternErrors[pattern] = err
+		}
+		patterns[pattern] = re
+	}
+	return re, patternErrors[pattern]
+}
+
+// pointerToken escapes the property name for a JSON pointer (RFC 6901)
+func pointerToken(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// instancePath returns the JSON pointer in the message of a property $id, e.g. #/properties/address/properties/city is /address/city
+func instancePath(id string) string {
+	var path strings.Builder
+	segments := strings.Split(strings.TrimPrefix(id, "#/"), "/")
+	for i := 1; i < len(segments); i += 2 {
+		path.WriteString("/" + pointerToken(segments[i]))
+	}
+	return path.String()
+}
+
+// additionalProperties returns false when the object can't have other properties and the schema of the other properties
+func additionalProperties(value interface{}) (allowed bool, prop *jsonProperty) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		prop = &jsonProperty{}
+		json.Unmarshal(data, prop)
+		return true, prop
+	}
+	return true, nil
+}
+
+func isJsonType(jsonType string, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return jsonType == "string"
+	case bool:
+		return jsonType == "boolean"
+	case json.Number:
+		if jsonType == "integer" {
+			f, err := v.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+		return jsonType == "number"
+	case []interface{}:
+		return jsonType == "array"
+	case map[string]interface{}:
+		return jsonType == "object"
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type TestKeywordSchema struct {
+	Name     string        `json:"name"`
+	Email    string        `json:"email"`
+	Birthday string        `json:"birthday"`
+	Created  string        `json:"created"`
+	Id       string        `json:"id"`
+	Age      int           `json:"age"`
+	Tags     []string      `json:"tags"`
+	Value    interface{}   `json:"value"`
+	Address  TestAddress   `json:"address"`
+	Lines    []interface{} `json:"lines"`
+}
+
+type TestAddress struct {
+	City string `json:"city"`
+}
+
+func newKeywordSchema(t *testing.T) Schema {
+	schema := Schema{
+		StructRef: &TestKeywordSchema{},
+	}
+	json.Unmarshal([]byte(`
+	{
+		"properties": {
+			"name": {"$id": "#/properties/name", "type": "string", "minLength": 3, "maxLength": 10, "pattern": "^[A-Za-z ]+$"},
+			"email": {"$id": "#/properties/email", "type": "string", "format": "email"},
+			"birthday": {"$id": "#/properties/birthday", "type": "string", "format": "date"},
+			"created": {"$id": "#/properties/created", "type": "string", "format": "date-time"},
+			"id": {"$id": "#/properties/id", "type": "string", "format": "uuid"},
+			"age": {"$id": "#/properties/age", "type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"tags": {"$id": "#/properties/tags", "type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string", "maxLength": 5}},
+			"value": {"$id": "#/properties/value", "type": "object", "oneOf": [{"type": "string"}, {"type": "integer"}]},
+			"address": {
+				"$id": "#/properties/address",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"city": {"$id": "#/properties/address/properties/city", "type": "string"}
+				}
+			},
+			"lines": {"$id": "#/properties/lines", "type": "array", "items": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/line"}]}}
+		},
+		"definitions": {
+			"line": {"type": "object", "properties": {"amount": {"type": "number", "minimum": 1}}}
+		}
+	}
+	`), &schema.jsonSchema)
+	AssertEqual(t, schema.validateSchema().FmtErrMsg, "")
+	return schema
+}
+
+func TestKeywordValid(t *testing.T) {
+	schema := newKeywordSchema(t)
+	body := TestKeywordSchema{}
+	soteErr := schema.Parse([]byte(`{"name": "Sote", "email": "user@sote.com", "birthday": "2021-02-28", "created": "2021-02-28T10:00:00Z",
+		"id": "5c2f0f3e-6d3a-4f0e-9a4b-2b1a0c0d9e8f", "age": 30, "tags": ["a", "b"], "value": 10, "address": {"city": "Austin"},
+		"lines": ["text", {"amount": 5}]}`), &body)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, body.Age, 30)
+}
+
+func TestKeywordViolations(t *testing.T) {
+	schema := newKeywordSchema(t)
+	for data, expected := range map[string]string{
+		`{"name": "So"}`:                     "207090: So (/name) is too small. Min size: 3 Actual size: 2",
+		`{"name": "Sote Applications"}`:      "207090: Sote Applications (/name) is too large. Max size: 10 Actual size: 17",
+		`{"name": "Sote_1"}`:                 "207060: Sote_1 (/name) contains special characters which are not allowed",
+		`{"email": "user.sote.com"}`:         "207050: user.sote.com (/email) is not a valid email address",
+		`{"birthday": "2021-02-30"}`:         "207070: 2021-02-30 (/birthday) is not a valid date",
+		`{"created": "2021-02-28 10:00"}`:    "207080: 2021-02-28 10:00 (/created) is not a valid timestamp. Format's are UTC, GMT or Zulu",
+		`{"id": "123"}`:                      "207060: 123 (/id) contains special characters which are not allowed",
+		`{"age": -1}`:                        "207095: -1 (/age) must be greater than 0 and less than 150",
+		`{"age": 150}`:                       "207095: 150 (/age) must be greater than 0 and less than 150",
+		`{"tags": []}`:                       "207090: [] (/tags) is too small. Min size: 1 Actual size: 0",
+		`{"tags": ["a", "b", "c"]}`:          "207090: [a b c] (/tags) is too large. Max size: 2 Actual size: 3",
+		`{"tags": ["a", "abcdef"]}`:          "207090: abcdef (/tags/1) is too large. Max size: 5 Actual size: 6",
+		`{"value": true}`:                    "200200: /value must be of type string",
+		`{"address": {"zip": "78701"}}`:      "200250: /address (zip) must contain one of these values: [city]",
+		`{"lines": [{"amount": 0}]}`:         "200200: /lines/0 must be of type string",
+		`{"lines": [{"amount": "1"}, "ok"]}`: "200200: /lines/0 must be of type string",
+	} {
+		body := TestKeywordSchema{}
+		AssertEqual(t, schema.Parse([]byte(data), &body).FmtErrMsg, expected)
+	}
+}
+
+func TestKeywordOneOf(t *testing.T) {
+	schema := Schema{
+		StructRef: &TestKeywordSchema{},
+	}
+	json.Unmarshal([]byte(`{"properties": {"value": {"$id": "#/properties/value", "type": "object",
+		"oneOf": [{"type": "string", "maxLength": 3}, {"type": "string", "minLength": 2}]}}}`), &schema.jsonSchema)
+	AssertEqual(t, schema.validateSchema().FmtErrMsg, "")
+	body := TestKeywordSchema{}
+	AssertEqual(t, schema.Parse([]byte(`{"value": "abcd"}`), &body).FmtErrMsg, "")
+	AssertEqual(t, schema.Parse([]byte(`{"value": "abc"}`), &body).FmtErrMsg,
+		"200200: /value must be of type exactly one of the oneOf schemas")
+}
+
+func TestKeywordAllErrors(t *testing.T) {
+	var (
+		list []violation
+	)
+	schema := newKeywordSchema(t)
+	schema.AllErrors = true
+	body := TestKeywordSchema{}
+	soteErr := schema.Parse([]byte(`{"name": "So", "age": 200, "tags": ["a", "abcdef"], "address": {"zip": "78701"}}`), &body)
+	AssertEqual(t, soteErr.ErrCode, 200250)
+	json.Unmarshal([]byte(soteErr.ErrorDetails[VIOLATIONSKEY]), &list)
+	AssertEqual(t, len(list), 4)
+	AssertEqual(t, list[0].Path, "/address/zip")
+	AssertEqual(t, list[0].Expected, "additionalProperties false")
+	AssertEqual(t, list[1].Path, "/age")
+	AssertEqual(t, list[1].Expected, "range 0 - 150")
+	AssertEqual(t, fmt.Sprint(list[1].Actual), "200")
+	AssertEqual(t, list[2].Message, "207090: So (/name) is too small. Min size: 3 Actual size: 2")
+	AssertEqual(t, list[3].Path, "/tags/1")
+
+	schema.AllErrors = false
+	soteErr = schema.Parse([]byte(`{"name": "So", "age": 200}`), &body)
+	AssertEqual(t, soteErr.FmtErrMsg, "207095: 200 (/age) must be greater than 0 and less than 150")
+	AssertEqual(t, len(soteErr.ErrorDetails), 0)
+}
+
+func TestKeywordAllErrorsRequired(t *testing.T) {
+	var (
+		list []violation
+	)
+	schema := Schema{
+		StructRef: &TestKeywordSchema{},
+		AllErrors: true,
+	}
+	json.Unmarshal([]byte(`{"required": ["name", "email"], "properties": {
+		"name": {"$id": "#/properties/name", "type": "string"},
+		"email": {"$id": "#/properties/email", "type": "string"},
+		"age": {"$id": "#/properties/age", "type": "integer", "maximum": 10}}}`), &schema.jsonSchema)
+	AssertEqual(t, schema.validateSchema().FmtErrMsg, "")
+	body := TestKeywordSchema{}
+	soteErr := schema.Parse([]byte(`{"age": 11}`), &body)
+	json.Unmarshal([]byte(soteErr.ErrorDetails[VIOLATIONSKEY]), &list)
+	AssertEqual(t, soteErr.ErrCode, 206200)
+	AssertEqual(t, len(list), 3)
+	AssertEqual(t, list[0].Path, "/email")
+	AssertEqual(t, list[0].Expected, "required")
+	AssertEqual(t, list[1].Path, "/name")
+	AssertEqual(t, list[2].Path, "/age")
+}
+
+func TestKeywordInstancePath(t *testing.T) {
+	AssertEqual(t, instancePath("#/properties/address/properties/city"), "/address/city")
+	AssertEqual(t, instancePath("#/properties/properties"), "/properties")
+	AssertEqual(t, pointerToken("a/b~c"), "a~1b~0c")
+	re, err := compilePattern("^[a-z]+$")
+	AssertEqual(t, err, nil)
+	again, _ := compilePattern("^[a-z]+$")
+	AssertEqual(t, again == re, true)
+	_, err = compilePattern("[")
+	AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,147 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	KVTIMEOUT           = time.Second
+	KVWRONGLASTSEQUENCE = 10071 // JetStream err_code of a publish whose expected last subject sequence isn't the last one
+)
+
+// kvBucket uses the stream layout of a JetStream KV bucket (stream KV_<name>, subjects $KV.<name>.<key>, one message per
+// key), the buckets are readable by the nats kv tools
+type kvBucket struct {
+	run  *Run
+	name string
+}
+
+// create adds the stream of the bucket when it doesn't exist, the keys expire after maxAge (zero never expires)
+func (b kvBucket) create(maxAge time.Duration) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var js nats.JetStreamContext
+	if js, soteErr = b.jetStream(); soteErr.ErrCode == nil {
+		if _, err := js.StreamInfo(b.stream()); err == nats.ErrStreamNotFound {
+			if _, err = js.AddStream(&nats.StreamConfig{Name: b.stream(), Subjects: []string{b.subject(">")},
+				MaxMsgsPerSubject: 1, MaxAge: maxAge, Storage: nats.FileStorage}); err != nil {
+				soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+			}
+		} else if err != nil {
+			soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+		}
+	}
+	return
+}
+
+// get returns the value and the revision of the key, a missing key has no value and the revision 0
+func (b kvBucket) get(key string) (value []byte, revision uint64, soteErr sError.SoteError) {
+	var (
+		reply *nats.Msg
+		resp  struct {
+			Error *struct {
+				Code        int    `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+			Message *struct {
+				Sequence uint64 `json:"seq"`
+				Data     []byte `json:"data"`
+			} `json:"message"`
+		}
+	)
+	if soteErr = b.connected(); soteErr.ErrCode != nil {
+		return
+	}
+	request, _ := json.Marshal(map[string]string{"last_by_subj": b.subject(key)})
+	reply, err := b.run.myMMPtr.NatsConnectionPtr.Request("$JS.API.STREAM.MSG.GET."+b.stream(), request, KVTIMEOUT)
+	if err == nil {
+		err = json.Unmarshal(reply.Data, &resp)
+	}
+	switch {
+	case err != nil:
+		soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+	case resp.Error != nil && resp.Error.Code == 404:
+	case resp.Error != nil:
+		soteErr = NewError(map[string]string{"ERROR": resp.Error.Description}).InternalError()
+	case resp.Message != nil:
+		value, revision = resp.Message.Data, resp.Message.Sequence
+	}
+	return
+}
+
+// update saves the value when the revision of the key is still revision (0 for a new key), conflict reports that another
+// writer changed the key. The PubAck is read here, the JetStream context of nats.go only returns its description.
+func (b kvBucket) update(key string, value []byte, revision uint64) (conflict bool, soteErr sError.SoteError) {
+	var reply *nats.Msg
+	if soteErr = b.connected(); soteErr.ErrCode != nil {
+		return
+	}
+	msg := nats.NewMsg(b.subject(key))
+	msg.Header.Set(nats.ExpectedLastSubjSeqHdr, strconv.FormatUint(revision, 10))
+	msg.Data = value
+	reply, err := b.run.myMMPtr.NatsConnectionPtr.RequestMsg(msg, KVTIMEOUT)
+	if err != nil {
+		return false, NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+	}
+	return pubAckConflict(reply.Data)
+}
+
+// pubAckConflict reads the PubAck of an update, the error KVWRONGLASTSEQUENCE is a conflict
+func pubAckConflict(data []byte) (conflict bool, soteErr sError.SoteError) {
+	var resp struct {
+		Error *struct {
+			Code        int    `json:"code"`
+			ErrCode     int    `json:"err_code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	switch err := json.Unmarshal(data, &resp); {
+	case err != nil:
+		soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+	case resp.Error != nil && resp.Error.ErrCode == KVWRONGLASTSEQUENCE:
+		conflict = true
+	case resp.Error != nil:
+		soteErr = NewError(map[string]string{"ERROR": resp.Error.Description}).InternalError()
+	}
+	return
+}
+
+func (b kvBucket) put(key string, value []byte) (soteErr sError.SoteError) {
+	var js nats.JetStreamContext
+	if js, soteErr = b.jetStream(); soteErr.ErrCode == nil {
+		if _, err := js.Publish(b.subject(key), value); err != nil {
+			soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+		}
+	}
+	return
+}
+
+func (b kvBucket) jetStream() (js nats.JetStreamContext, soteErr sError.SoteError) {
+	if soteErr = b.connected(); soteErr.ErrCode == nil {
+		var err error
+		if js, err = b.run.myMMPtr.NatsConnectionPtr.JetStream(); err != nil {
+			soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+		}
+	}
+	return
+}
+
+func (b kvBucket) connected() (soteErr sError.SoteError) {
+	if b.run == nil || b.run.myMMPtr == nil || b.run.myMMPtr.NatsConnectionPtr == nil {
+		soteErr = sError.GetSError(209499, nil, sError.EmptyMap)
+	}
+	return
+}
+
+func (b kvBucket) stream() string {
+	return "KV_" + b.name
+}
+
+func (b kvBucket) subject(key string) string {
+	return "$KV." + b.name + "." + key
+}
@@ -2,6 +2,8 @@ package sHelper
 
 import (
 	"testing"
+
+	"gitlab.com/soteapps/packages/v2021/sTesting"
 )
 
 type fakeT struct {
@@ -14,6 +16,12 @@ func (fakeT) Helper() {
 func (fakeT) Fatal(args ...interface{}) {
 }
 
+// MockToken returns a token signed by a local issuer trusted in the target environment of env
+func MockToken(t *testing.T, env Environment, claims ...map[string]interface{}) string {
+	t.Helper()
+	return sTesting.Token(t, env.TargetEnvironment, claims...)
+}
+
 func TestAssertEqual(t *testing.T) {
 	AssertEqual(t, "HELLO", "HELLO")
 	AssertEqual(&fakeT{}, "HELLO", "WORLD")
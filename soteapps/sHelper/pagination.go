@@ -0,0 +1,243 @@
+package sHelper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+const (
+	COUNTNONE     = "none"     // Pagination.Total is not calculated and set to -1
+	COUNTEXACT    = "exact"    // Pagination.Total is calculated with count(*) ignoring the cursor
+	COUNTESTIMATE = "estimate" // Pagination.Total is the row estimate of the planner for the query ignoring the cursor
+
+	CURSORNEXT     = "next"
+	CURSORPREVIOUS = "previous"
+)
+
+type keyset struct {
+	count    string
+	estimate string        // SELECT of the rows ignoring the cursor, see keysetBuild
+	values   []interface{} // values of estimate
+	keys     []string
+	columns  []string
+	desc     []bool
+	cursor   *cursor
+	first    []interface{}
+	last     []interface{}
+	rows     int64
+	more     bool
+}
+
+type cursor struct {
+	Direction string        `json:"d"`
+	Values    []interface{} `json:"v"`
+}
+
+// CursorPagination replaces LIMIT/OFFSET by a keyset (seek) condition on the sort columns of the filter.
+// The keys are appended to the sort columns as a unique tie-breaker (Ex: the primary key) and all of them must be NOT NULL.
+// The page is selected by FilterHeaderSchema.Cursor and Pagination.Next/Previous return the opaque cursors of the adjacent pages.
+func (q Query) CursorPagination(count string, keys ...string) Query {
+	q.Result.Pagination = &Pagination{
+		keyset: &keyset{
+			count: count,
+			keys:  keys,
+		},
+	}
+	return q
+}
+
+func (q *Query) keysetSelect() {
+	p := q.Result.Pagination
+	ks := p.keyset
+	ks.columns, ks.desc = []string{}, []bool{}
+	for _, name := range q.Filter.SortAsc {
+		ks.columns, ks.desc = append(ks.columns, q.column(name)), append(ks.desc, false)
+	}
+	for _, name := range q.Filter.SortDesc {
+		ks.columns, ks.desc = append(ks.columns, q.column(name)), append(ks.desc, true)
+	}
+	for _, name := range ks.keys {
+		if !contains(ks.columns, name) {
+			ks.columns, ks.desc = append(ks.columns, name), append(ks.desc, false)
+		}
+	}
+	if q.Filter.Cursor != "" {
+		ks.cursor, q.soteErr = decodeCursor(q.Filter.Cursor, len(ks.columns))
+	}
+	switch ks.count {
+	case COUNTEXACT: // see keysetBuild
+	case COUNTESTIMATE:
+		p.Estimated = true
+	default:
+		p.Total = -1
+	}
+
+	backward := ks.backward()
+	orderBy := make([]string, len(ks.columns))
+	for i, name := range ks.columns {
+		if ks.desc[i] != backward {
+			orderBy[i] = name + " DESC"
+		} else {
+			orderBy[i] = name + " ASC"
+		}
+	}
+	q.OrderBy = strings.Join(orderBy, ", ")
+	q.Offset = nil
+	p.Offset = 0
+	if q.Limit != nil {
+		limit := *q.Limit + 1 // one more row tells if there is another page
+		q.Limit = &limit
+	}
+	q.Sql.WriteString(strings.Join(ks.columns, ", ") + ", ")
+}
+
+// keysetBuild adds the total count ignoring the cursor and the seek condition of the cursor to the query
+func (q *Query) keysetBuild(from string) {
+	ks := q.Result.Pagination.keyset
+	if ks.count == COUNTEXACT {
+		count := fmt.Sprintf("(SELECT count(*) FROM %v), ", from)
+		if q.Where != "" {
+			count = fmt.Sprintf("(SELECT count(*) FROM %v WHERE %v), ", from, q.Where)
+		}
+		sql := strings.TrimPrefix(q.Sql.String(), "SELECT ")
+		q.Sql.Reset()
+		q.Sql.WriteString("SELECT " + count + sql)
+	} else if ks.count == COUNTESTIMATE {
+		ks.estimate = "SELECT 1 FROM " + from
+		if q.Where != "" {
+			ks.estimate += " WHERE " + q.Where
+		}
+		ks.values = append([]interface{}{}, q.Values...)
+	}
+	if ks.cursor != nil {
+		backward := ks.backward()
+		params := placeholders(len(q.Values)+1, len(ks.columns))
+		q.Values = append(q.Values, ks.cursor.Values...)
+		conditions := make([]string, len(ks.columns))
+		for i, name := range ks.columns {
+			op := ">"
+			if ks.desc[i] != backward {
+				op = "<"
+			}
+			condition := []string{}
+			for j := 0; j < i; j++ {
+				condition = append(condition, fmt.Sprintf("%v = %v", ks.columns[j], params[j]))
+			}
+			conditions[i] = strings.Join(append(condition, fmt.Sprintf("%v %v %v", name, op, params[i])), " AND ")
+		}
+		q.andWhere(fmt.Sprintf("(%v)", strings.Join(conditions, " OR ")))
+	}
+}
+
+func (q *Query) keysetScan(tCols []interface{}) {
+	p := q.Result.Pagination
+	ks := p.keyset
+	offset := 0
+	if ks.count == COUNTEXACT {
+		p.Total = tCols[0].(int64)
+		offset = 1
+	}
+	values := tCols[offset : offset+len(ks.columns)]
+	ks.rows++
+	if p.Limit > 0 && ks.rows > p.Limit {
+		ks.more = true
+	} else if ks.backward() {
+		q.Result.Items = append([]interface{}{q.row(tCols, offset+len(ks.columns))}, q.Result.Items...)
+		if ks.last == nil {
+			ks.last = values
+		}
+		ks.first = values
+	} else {
+		q.Result.Items = append(q.Result.Items, q.row(tCols, offset+len(ks.columns)))
+		if ks.first == nil {
+			ks.first = values
+		}
+		ks.last = values
+	}
+
+	p.Next, p.Previous = "", ""
+	if ks.first == nil {
+		return
+	}
+	if ks.backward() {
+		p.Next = encodeCursor(CURSORNEXT, ks.last)
+		if ks.more {
+			p.Previous = encodeCursor(CURSORPREVIOUS, ks.first)
+		}
+	} else {
+		if ks.more {
+			p.Next = encodeCursor(CURSORNEXT, ks.last)
+		}
+		if ks.cursor != nil {
+			p.Previous = encodeCursor(CURSORPREVIOUS, ks.first)
+		}
+	}
+}
+
+// estimate sets Pagination.Total to the rows planned for the query ignoring the cursor (EXPLAIN), the tenant scope and the
+// conditions of the query apply to the estimate
+func (q Query) estimate(r *Run) (soteErr sError.SoteError) {
+	var (
+		data []byte
+		plan []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+	)
+	p := q.Result.Pagination
+	if p == nil || p.keyset == nil || p.keyset.count != COUNTESTIMATE {
+		return
+	}
+	e := Query{Sql: bytes.NewBufferString(p.keyset.estimate), Values: p.keyset.values, with: q.with}
+	if e.withPrefix(); e.soteErr.ErrCode != nil {
+		return e.soteErr
+	}
+	tRows, err := r.statement("Exec", q.timeout(r), true, "EXPLAIN (FORMAT JSON) "+e.Sql.String(), e.Values...)
+	if soteErr = q.GetError(err); soteErr.ErrCode == nil {
+		for tRows.Next() {
+			tRows.Scan(&data)
+		}
+		q.Close(tRows, &soteErr)
+	}
+	if soteErr.ErrCode == nil {
+		if err = json.Unmarshal(data, &plan); err != nil || len(plan) == 0 {
+			return NewError().InvalidJson("EXPLAIN")
+		}
+		p.Total = int64(plan[0].Plan.Rows)
+	}
+	return
+}
+
+func (ks *keyset) backward() bool {
+	return ks.cursor != nil && ks.cursor.Direction == CURSORPREVIOUS
+}
+
+func encodeCursor(direction string, values []interface{}) string {
+	data, _ := json.Marshal(cursor{Direction: direction, Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, total int) (c *cursor, soteErr sError.SoteError) {
+	c = &cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		err = decoder.Decode(c)
+	}
+	if err != nil || len(c.Values) != total || (c.Direction != CURSORNEXT && c.Direction != CURSORPREVIOUS) {
+		return nil, NewError().InvalidJson("cursor")
+	}
+	for i, v := range c.Values {
+		if n, ok := v.(json.Number); ok {
+			c.Values[i] = n.String() // sent as text, postgres casts it to the column type
+		}
+	}
+	return
+}
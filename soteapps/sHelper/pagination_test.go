@@ -0,0 +1,132 @@
+package sHelper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+)
+
+func newCursorQuery(cursor string, count string) Query {
+	var limit int64 = 2
+	return Query{
+		Table: "TABLE1",
+		Filter: &FilterHeaderSchema{
+			Items:    []string{"COL1", "COL2"},
+			Limit:    &limit,
+			SortDesc: []string{"CREATED"},
+			Equal:    map[string]interface{}{"COL1": "Hello"},
+			Cursor:   cursor,
+		},
+	}.CursorPagination(count, "ID").Select()
+}
+
+func scanCursorRows(query *Query, rows ...[]interface{}) {
+	for _, values := range rows {
+		v := values
+		query.Scan(sDatabase.Rows{IValues: func() ([]interface{}, error) { return v, nil }})
+	}
+}
+
+func TestPaginationCursorFirstPage(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := newCursorQuery("", COUNTNONE)
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT CREATED, ID, COL1, COL2 FROM sote.TABLE1 WHERE COL1 = $1 ORDER BY CREATED DESC, ID ASC LIMIT 3")
+	scanCursorRows(&query, []interface{}{"2021-05-03", 3, "Hello", 1}, []interface{}{"2021-05-02", 2, "Hello", 2},
+		[]interface{}{"2021-05-01", 1, "Hello", 3})
+	AssertEqual(t, len(query.Result.Items), 2)
+	AssertEqual(t, query.Result.Pagination.Total, int64(-1))
+	AssertEqual(t, query.Result.Pagination.Limit, int64(2))
+	AssertEqual(t, query.Result.Pagination.Previous, "")
+	AssertEqual(t, query.Result.Pagination.Next, encodeCursor(CURSORNEXT, []interface{}{"2021-05-02", 2}))
+}
+
+func TestPaginationCursorNextPage(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, fmt.Sprintf("%v", args), "[Hello 2021-05-02 2]")
+		return nil, nil
+	}
+	query := newCursorQuery(encodeCursor(CURSORNEXT, []interface{}{"2021-05-02", 2}), COUNTEXACT)
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "SELECT (SELECT count(*) FROM sote.TABLE1 WHERE COL1 = $1), CREATED, ID, COL1, COL2 FROM sote.TABLE1 "+
+		"WHERE (COL1 = $1) AND (CREATED < $2 OR CREATED = $2 AND ID > $3) ORDER BY CREATED DESC, ID ASC LIMIT 3")
+	scanCursorRows(&query, []interface{}{int64(3), "2021-05-01", 1, "Hello", 3})
+	AssertEqual(t, query.Result.Pagination.Total, int64(3))
+	AssertEqual(t, query.Result.Pagination.Next, "")
+	AssertEqual(t, query.Result.Pagination.Previous, encodeCursor(CURSORPREVIOUS, []interface{}{"2021-05-01", 1}))
+}
+
+func TestPaginationCursorPreviousPage(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	query := newCursorQuery(encodeCursor(CURSORPREVIOUS, []interface{}{"2021-05-01", 1}), COUNTNONE)
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT CREATED, ID, COL1, COL2 FROM sote.TABLE1 "+
+		"WHERE (COL1 = $1) AND (CREATED > $2 OR CREATED = $2 AND ID < $3) ORDER BY CREATED ASC, ID DESC LIMIT 3")
+	scanCursorRows(&query, []interface{}{"2021-05-02", 2, "Hello", 2}, []interface{}{"2021-05-03", 3, "Hello", 1})
+	AssertEqual(t, fmt.Sprint(query.Result.Items), "[map[COL1:Hello COL2:1] map[COL1:Hello COL2:2]]")
+	AssertEqual(t, query.Result.Pagination.Previous, "")
+	AssertEqual(t, query.Result.Pagination.Next, encodeCursor(CURSORNEXT, []interface{}{"2021-05-02", 2}))
+}
+
+func TestPaginationCursorEstimate(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		next := true
+		return sDatabase.Rows{
+			IEerr: func() error { return nil },
+			INext: func() bool { defer func() { next = false }(); return next },
+			IScan: func(dest ...interface{}) error {
+				if strings.HasPrefix(sql, "EXPLAIN") {
+					// the estimate has the conditions of the query, not the cursor
+					AssertEqual(t, sql, "EXPLAIN (FORMAT JSON) SELECT 1 FROM sote.TABLE1 WHERE COL1 = $1")
+					AssertEqual(t, len(args), 1)
+					*dest[0].(*[]byte) = []byte(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 1000}}]`)
+				}
+				return nil
+			},
+		}, nil
+	}
+	query := newCursorQuery(encodeCursor(CURSORNEXT, []interface{}{"2021-05-01", 1}), COUNTESTIMATE)
+	_, soteErr := query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Result.Pagination.Total, int64(1000))
+	AssertEqual(t, query.Result.Pagination.Estimated, true)
+}
+
+func TestPaginationCursorEstimateTenant(t *testing.T) {
+	var estimate string
+	AddTenantTable("TABLE1")
+	defer RemoveTenantTable("TABLE1")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		if strings.HasPrefix(sql, "EXPLAIN") {
+			estimate = fmt.Sprint(sql, args)
+		}
+		return sDatabase.Rows{IEerr: func() error { return nil }, INext: func() bool { return false }}, nil
+	}
+	var limit int64 = 2
+	query := Query{Table: "TABLE1", Filter: &FilterHeaderSchema{Items: []string{"COL1"}, Limit: &limit}}.
+		TenantScope(RequestHeaderSchema{OrganizationId: 10003}).CursorPagination(COUNTESTIMATE, "ID").Select()
+	query.Exec(run)
+	// the estimate is the rows of the organization, not of the table
+	AssertEqual(t, estimate, "EXPLAIN (FORMAT JSON) SELECT 1 FROM sote.TABLE1 WHERE organizations_id = $1[10003]")
+}
+
+func TestPaginationInvalidCursor(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	_, soteErr := newCursorQuery("INVALID", COUNTNONE).Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "207110: cursor couldn't be parsed - Invalid JSON error")
+	_, soteErr = newCursorQuery(encodeCursor(CURSORNEXT, []interface{}{1}), COUNTNONE).Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "207110: cursor couldn't be parsed - Invalid JSON error")
+}
@@ -24,6 +24,8 @@ func (p Parameter) Init() Environment {
 	var (
 		targetEnvironment string
 		configHomeDir     string
+		schemaDirectory   string
+		isOffline         = false
 		applicationName   = ENVDEFAULTAPPNAME
 		isVerbose         = false
 	)
@@ -60,6 +62,10 @@ func (p Parameter) Init() Environment {
 	flaggy.String(&configHomeDir, "c", "config",
 		"Defines the base directory relative to which user-specific configuration files should be stored. If $XDG_CONFIG_HOME is either not set or empty, "+
 			"a default equal to $HOME/.config should be used.")
+	flaggy.String(&schemaDirectory, "s", "schemaDir",
+		"Local copies of the message schemas, a schema URL is resolved as <schemaDir>/<host>/<path> before it is downloaded.")
+	flaggy.Bool(&isOffline, "o", "offline",
+		"Only resolve the message schemas from the embedded files, the schema directory and the schema cache (no network).")
 	flaggy.Bool(&isVerbose, "v", "verbose",
 		"Verbose output: log all tests as they are run. Also print all text from Log and Logf calls even if the test succeeds.")
 
@@ -71,6 +77,10 @@ func (p Parameter) Init() Environment {
 		sLogger.SetLogLevelDebug()
 	}
 	sLogger.SetLogMessagePrefix(applicationName)
+	if schemaDirectory != "" {
+		SetSchemaDirectory(schemaDirectory)
+	}
+	SetSchemaOffline(isOffline)
 
 	if targetEnvironment == "" {
 		if configHomeDir != "" {
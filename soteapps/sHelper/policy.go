@@ -0,0 +1,36 @@
+package sHelper
+
+import (
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// AddPolicy allows the roles to send the messages of the subject (NATS wildcards are allowed), the roles of the requestor
+// are the groups of the verified token. The other roles get the error 100100 in the reply and the listener isn't called.
+func AddPolicy(subject string, roles ...string) {
+	sLogger.DebugMethod()
+	sAuthentication.AddPolicy(subject, roles...)
+}
+
+func RemovePolicy(subject string) {
+	sAuthentication.RemovePolicy(subject)
+}
+
+// authorize checks the roles of the requestor against the policy of the subject
+func (s *Subscriber) authorize(msg *Msg) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var identity *Identity
+	if !sAuthentication.HasPolicy(msg.Subject) {
+		return
+	}
+	schema := s.msgSchema(msg)
+	header := schema.msgHeader(msg)
+	if identity, soteErr = schema.verify(s.Run.Env, msg, header); soteErr.ErrCode == nil {
+		soteErr = sAuthentication.Authorize(msg.Subject, identity.Groups)
+	}
+	if soteErr.ErrCode != nil {
+		s.PublishMessage(header, soteErr, nil)
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package sHelper
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+func TestPolicyAuthorize(t *testing.T) {
+	var reply sError.SoteError
+	AddPolicy("test-subject", "admin")
+	defer RemovePolicy("test-subject")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		AssertEqual(t, header.AwsUserName, "soteuser")
+		reply = soteErr
+		return sError.SoteError{}
+	}
+	admin := MockToken(t, s.Run.Env, map[string]interface{}{"cognito:groups": []string{"admin"}})
+	msg := &Msg{Subject: "test-subject", Data: []byte(`{"request-header": {"aws-user-name": "soteuser", "json-web-token": "` + admin + `"}}`)}
+	AssertEqual(t, s.authorize(msg).ErrCode, nil)
+	AssertEqual(t, reply.ErrCode, nil)
+
+	// the role-list of the client isn't trusted
+	driver := MockToken(t, s.Run.Env, map[string]interface{}{"cognito:groups": []string{"driver"}})
+	msg = &Msg{Subject: "test-subject", Data: []byte(`{"aws-user-name": "soteuser", "role-list": ["admin"], "json-web-token": "` + driver + `"}`)}
+	AssertEqual(t, s.authorize(msg).ErrCode, 100100)
+	AssertEqual(t, strings.Contains(reply.FmtErrMsg, "Your roles [driver] are not authorized to test-subject"), true)
+
+	msg.Subject = "other-subject"
+	AssertEqual(t, s.authorize(msg).ErrCode, nil)
+}
+
+func TestPolicyTokenValidatedOnce(t *testing.T) {
+	AddPolicy("test-subject", "admin")
+	defer RemovePolicy("test-subject")
+	s := newSubscriber()
+	s.Schema = &Schema{}
+	admin := MockToken(t, s.Run.Env, map[string]interface{}{"cognito:groups": []string{"admin"}, "username": "soteuser",
+		"custom:organizations-id": "10003"})
+	msg := &Msg{Subject: "test-subject", Data: []byte(`{"request-header": {"aws-user-name": "soteuser", "organizations-id": 10003, ` +
+		`"json-web-token": "` + admin + `"}}`)}
+	AssertEqual(t, s.authorize(msg).ErrCode, nil)
+	AssertEqual(t, msg.Identity.Username, "soteuser")
+
+	// the listener gets the identity verified by the policy, the request header is still checked against it
+	verified := msg.Identity
+	_, identity, soteErr := s.Schema.identify(s.Run.Env, msg)
+	AssertEqual(t, soteErr.ErrCode, nil)
+	AssertEqual(t, identity == verified, true)
+	msg.Data = []byte(`{"request-header": {"aws-user-name": "other", "organizations-id": 10003, "json-web-token": "` + admin + `"}}`)
+	_, _, soteErr = s.Schema.identify(s.Run.Env, msg)
+	AssertEqual(t, soteErr.ErrCode, 208360)
+}
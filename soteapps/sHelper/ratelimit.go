@@ -0,0 +1,285 @@
+package sHelper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sConfigParams"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	RATELIMITUSER         = "user"         // one token bucket per username of the verified token
+	RATELIMITORGANIZATION = "organization" // one token bucket per organization of the verified token
+	RATELIMITANONYMOUS    = "anonymous"    // the requestors without verified token share one token bucket
+	RATELIMITEXPIRES      = time.Hour      // idle token buckets are removed, a bucket must refill within the hour
+	RATELIMITRETRIES      = 3              // updates of a shared token bucket changed by another replica
+)
+
+// RateLimit is a token bucket per user or organization: Burst requests at once, refilled with Rate requests per second
+type RateLimit struct {
+	Subject string  `json:"subject"` // NATS wildcards are allowed, e.g. bsl.>
+	Key     string  `json:"key"`     // RATELIMITUSER or RATELIMITORGANIZATION
+	Rate    float64 `json:"rate"`
+	Burst   float64 `json:"burst"`
+}
+
+// RateLimitStore keeps the token buckets, KVRateLimits shares them between the replicas of a service
+type RateLimitStore interface {
+	// Take removes a token from the bucket of the key, retryAfter is the wait for the next token when the bucket is empty
+	Take(key string, limit RateLimit, now time.Time) (ok bool, retryAfter time.Duration, soteErr sError.SoteError)
+}
+
+type tokenBucket struct {
+	Tokens  float64 `json:"tokens"`
+	Updated int64   `json:"updated"` // unix nanoseconds
+}
+
+// localRateLimits keeps the token buckets of this replica
+type localRateLimits struct {
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	prunedAt time.Time
+}
+
+// KVRateLimits shares the token buckets in the JetStream KV bucket, a token bucket is only saved when no other replica
+// changed it since it was read
+type KVRateLimits struct {
+	Run    *Run
+	Bucket string
+}
+
+var (
+	rateLimits     []RateLimit
+	rateLimitsMu   sync.RWMutex
+	localLimits                   = &localRateLimits{buckets: map[string]*tokenBucket{}}
+	rateLimitStore RateLimitStore = localLimits
+	rateLimitNow                  = time.Now
+)
+
+// AddRateLimit limits the messages of the subject sent by every user or organization, the limit replaces the limit with
+// the same subject and key. The requestors over the limit get the error 100300 and the listener isn't called.
+func AddRateLimit(limit RateLimit) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	if soteErr = limit.validate(); soteErr.ErrCode == nil {
+		rateLimitsMu.Lock()
+		defer rateLimitsMu.Unlock()
+		for i, current := range rateLimits {
+			if current.Subject == limit.Subject && current.Key == limit.Key {
+				rateLimits[i] = limit
+				return
+			}
+		}
+		rateLimits = append(rateLimits, limit)
+	}
+	return
+}
+
+// RemoveRateLimit removes the user and organization limits of the subject
+func RemoveRateLimit(subject string) {
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+	kept := rateLimits[:0]
+	for _, limit := range rateLimits {
+		if limit.Subject != subject {
+			kept = append(kept, limit)
+		}
+	}
+	rateLimits = kept
+}
+
+// SetRateLimits replaces the rate limits with the JSON list of limits, e.g.
+// [{"subject": "bsl.>", "key": "organization", "rate": 50, "burst": 100}]
+func SetRateLimits(config string) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var limits []RateLimit
+	if err := json.Unmarshal([]byte(config), &limits); err != nil {
+		soteErr = NewError(map[string]string{"ERROR": err.Error()}).InvalidJson(sConfigParams.RATELIMITSKEY)
+		sLogger.Info(soteErr.FmtErrMsg)
+		return
+	}
+	for _, limit := range limits {
+		if soteErr = limit.validate(); soteErr.ErrCode != nil {
+			return
+		}
+	}
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+	rateLimits = limits
+	return
+}
+
+// LoadRateLimits sets the rate limits of the RATE_LIMITS parameter of the application, a missing parameter removes the limits
+func LoadRateLimits(env Environment) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var config string
+	if config, soteErr = sConfigParams.GetRateLimits(env.ApplicationName, env.TargetEnvironment); soteErr.ErrCode == 109999 {
+		config, soteErr = "[]", sError.SoteError{}
+	}
+	if soteErr.ErrCode == nil {
+		soteErr = SetRateLimits(config)
+	}
+	return
+}
+
+// SetRateLimitStore shares the token buckets in the store (e.g. KVRateLimits), nil keeps the token buckets in this replica.
+// The token buckets of this replica are used while the store fails.
+func SetRateLimitStore(store RateLimitStore) {
+	sLogger.DebugMethod()
+	if store == nil {
+		store = localLimits
+	}
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+	rateLimitStore = store
+}
+
+// rateLimit takes a token from the buckets of the requestor for every limit matching the subject, the requestor is the
+// verified token of the message (see Schema.verify), the request header isn't trusted. The organization limits of a token
+// without organization claim are kept per token subject.
+func (s *Subscriber) rateLimit(msg *Msg) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	limits, store := matchingRateLimits(msg.Subject)
+	if len(limits) == 0 {
+		return
+	}
+	schema := s.msgSchema(msg)
+	header := schema.msgHeader(msg)
+	identity, verifyErr := schema.verify(s.Run.Env, msg, header)
+	now := rateLimitNow()
+	for _, limit := range limits {
+		var id string // the anonymous bucket has no id
+		switch {
+		case verifyErr.ErrCode != nil:
+		case limit.Key == RATELIMITUSER && identity.Username != "":
+			id = identity.Username
+		case limit.Key == RATELIMITUSER:
+			id = identity.Subject // a service without user
+		case identity.HasOrganization():
+			id = strconv.Itoa(identity.OrganizationId)
+		default:
+			id = identity.Subject // the token doesn't vouch for an organization (e.g. Cognito access tokens)
+		}
+		key := limit.Key + "." + base64.RawURLEncoding.EncodeToString([]byte(limit.Subject))
+		if id == "" {
+			key, id = RATELIMITANONYMOUS+"."+key, RATELIMITANONYMOUS
+		} else {
+			key += "." + base64.RawURLEncoding.EncodeToString([]byte(id))
+		}
+		ok, retryAfter, storeErr := store.Take(key, limit, now)
+		if storeErr.ErrCode != nil {
+			sLogger.Info(storeErr.FmtErrMsg)
+			ok, retryAfter, _ = localLimits.Take(key, limit, now)
+		}
+		if !ok {
+			soteErr = NewError(map[string]string{limit.Key: id, "retry-after": retryAfter.Round(time.Millisecond).String()}).
+				TooManyRequests(msg.Subject)
+			sLogger.Info(soteErr.FmtErrMsg)
+			s.PublishMessage(header, soteErr, nil)
+			return
+		}
+	}
+	return
+}
+
+func matchingRateLimits(subject string) (limits []RateLimit, store RateLimitStore) {
+	rateLimitsMu.RLock()
+	defer rateLimitsMu.RUnlock()
+	for _, limit := range rateLimits {
+		if sAuthentication.MatchSubject(limit.Subject, subject) {
+			limits = append(limits, limit)
+		}
+	}
+	return limits, rateLimitStore
+}
+
+func (l RateLimit) validate() (soteErr sError.SoteError) {
+	switch {
+	case l.Subject == "":
+		soteErr = NewError().MustBePopulated("RateLimit.Subject")
+	case l.Key != RATELIMITUSER && l.Key != RATELIMITORGANIZATION:
+		soteErr = NewError().AllowValues("RateLimit.Key", l.Key, []string{RATELIMITUSER, RATELIMITORGANIZATION})
+	case l.Rate <= 0:
+		soteErr = NewError().MustBePopulated("RateLimit.Rate")
+	case l.Burst < 1:
+		soteErr = NewError().MustBePopulated("RateLimit.Burst")
+	}
+	return
+}
+
+// take refills the bucket since the last request and removes a token, a new bucket is full
+func (b *tokenBucket) take(limit RateLimit, now time.Time) (ok bool, retryAfter time.Duration) {
+	if b.Updated == 0 {
+		b.Tokens, b.Updated = limit.Burst, now.UnixNano()
+	} else if elapsed := now.Sub(time.Unix(0, b.Updated)); elapsed > 0 { // the clocks of the replicas may differ
+		b.Tokens, b.Updated = b.Tokens+elapsed.Seconds()*limit.Rate, now.UnixNano()
+		if b.Tokens > limit.Burst {
+			b.Tokens = limit.Burst
+		}
+	}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.Tokens) / limit.Rate * float64(time.Second))
+}
+
+func (l *localRateLimits) Take(key string, limit RateLimit, now time.Time) (ok bool, retryAfter time.Duration, soteErr sError.SoteError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.prunedAt) > RATELIMITEXPIRES {
+		for bucketKey, bucket := range l.buckets {
+			if now.Sub(time.Unix(0, bucket.Updated)) > RATELIMITEXPIRES {
+				delete(l.buckets, bucketKey)
+			}
+		}
+		l.prunedAt = now
+	}
+	bucket, found := l.buckets[key]
+	if !found {
+		bucket = &tokenBucket{}
+		l.buckets[key] = bucket
+	}
+	ok, retryAfter = bucket.take(limit, now)
+	return
+}
+
+// CreateBucket adds the stream of the bucket when it doesn't exist, the idle token buckets expire after RATELIMITEXPIRES
+func (k KVRateLimits) CreateBucket() sError.SoteError {
+	sLogger.DebugMethod()
+	return k.bucket().create(RATELIMITEXPIRES)
+}
+
+func (k KVRateLimits) Take(key string, limit RateLimit, now time.Time) (ok bool, retryAfter time.Duration, soteErr sError.SoteError) {
+	var (
+		value    []byte
+		revision uint64
+		conflict bool
+	)
+	for attempt := 0; attempt < RATELIMITRETRIES; attempt++ {
+		bucket := tokenBucket{}
+		if value, revision, soteErr = k.bucket().get(key); soteErr.ErrCode != nil {
+			return
+		}
+		if value != nil {
+			json.Unmarshal(value, &bucket)
+		}
+		if ok, retryAfter = bucket.take(limit, now); !ok {
+			return
+		}
+		value, _ = json.Marshal(bucket)
+		if conflict, soteErr = k.bucket().update(key, value, revision); !conflict {
+			return
+		}
+	}
+	return false, 0, NewError(map[string]string{"ERROR": key + " is updated by too many replicas"}).InternalError()
+}
+
+func (k KVRateLimits) bucket() kvBucket {
+	return kvBucket{run: k.Run, name: k.Bucket}
+}
@@ -0,0 +1,145 @@
+package sHelper
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	var (
+		now    = time.Now()
+		limit  = RateLimit{Subject: "test-subject", Key: RATELIMITUSER, Rate: 1, Burst: 2}
+		bucket = tokenBucket{}
+	)
+	ok, _ := bucket.take(limit, now)
+	AssertEqual(t, ok, true)
+	ok, _ = bucket.take(limit, now)
+	AssertEqual(t, ok, true)
+	ok, retryAfter := bucket.take(limit, now)
+	AssertEqual(t, ok, false)
+	AssertEqual(t, retryAfter, time.Second)
+	ok, retryAfter = bucket.take(limit, now.Add(500*time.Millisecond))
+	AssertEqual(t, ok, false)
+	AssertEqual(t, retryAfter, 500*time.Millisecond)
+	ok, _ = bucket.take(limit, now.Add(time.Second))
+	AssertEqual(t, ok, true)
+	// the bucket doesn't refill over the burst
+	bucket.take(limit, now.Add(time.Hour))
+	AssertEqual(t, bucket.Tokens, float64(1))
+}
+
+// rateLimitMsg returns a message of the user with a verified token, the request header claims to be the user "header"
+func rateLimitMsg(t *testing.T, s *Subscriber, subject, username string) *Msg {
+	token := MockToken(t, s.Run.Env, map[string]interface{}{"username": username, "custom:organizations-id": "10003"})
+	return &Msg{Subject: subject, Data: []byte(`{"request-header": {"json-web-token": "` + token +
+		`", "aws-user-name": "header", "organizations-id": 10004}}`)}
+}
+
+func TestRateLimitSubscriber(t *testing.T) {
+	var reply sError.SoteError
+	now := time.Now()
+	rateLimitNow = func() time.Time { return now }
+	defer func() { rateLimitNow = time.Now }()
+	AssertEqual(t, AddRateLimit(RateLimit{Subject: "test-subject.>", Key: RATELIMITUSER, Rate: 1, Burst: 1}).FmtErrMsg, "")
+	AssertEqual(t, AddRateLimit(RateLimit{Subject: "test-subject.>", Key: RATELIMITORGANIZATION, Rate: 1, Burst: 2}).FmtErrMsg, "")
+	defer RemoveRateLimit("test-subject.>")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		reply = soteErr
+		return sError.SoteError{}
+	}
+	msg := rateLimitMsg(t, s, "test-subject.add", "soteuser")
+	AssertEqual(t, s.rateLimit(msg).ErrCode, nil)
+	soteErr := s.rateLimit(msg)
+	AssertEqual(t, soteErr.ErrCode, 100300)
+	AssertEqual(t, reply.ErrorDetails["user"], "soteuser") // the token, not the request header
+	AssertEqual(t, reply.ErrorDetails["retry-after"], "1s")
+
+	// the users of the organization share the organization limit
+	AssertEqual(t, s.rateLimit(rateLimitMsg(t, s, "test-subject.add", "other")).ErrCode, nil)
+	AssertEqual(t, s.rateLimit(rateLimitMsg(t, s, "test-subject.add", "third")).ErrorDetails["organization"], "10003")
+
+	now = now.Add(time.Second)
+	AssertEqual(t, s.rateLimit(msg).ErrCode, nil)
+	AssertEqual(t, s.rateLimit(&Msg{Subject: "other-subject", Data: msg.Data}).ErrCode, nil)
+}
+
+func TestRateLimitWithoutOrganization(t *testing.T) {
+	AddRateLimit(RateLimit{Subject: "test-organization", Key: RATELIMITORGANIZATION, Rate: 1, Burst: 1})
+	defer RemoveRateLimit("test-organization")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		return sError.SoteError{}
+	}
+	// the tokens without organization claim have a token bucket per subject, not the anonymous one
+	first := &Msg{Subject: "test-organization", Data: []byte(`{"request-header": {"json-web-token": "` +
+		MockToken(t, s.Run.Env, map[string]interface{}{"sub": "first"}) + `", "aws-user-name": "soteuser", "organizations-id": 10003}}`)}
+	second := &Msg{Subject: "test-organization", Data: []byte(`{"request-header": {"json-web-token": "` +
+		MockToken(t, s.Run.Env, map[string]interface{}{"sub": "second"}) + `", "aws-user-name": "soteuser", "organizations-id": 10003}}`)}
+	AssertEqual(t, s.rateLimit(first).ErrCode, nil)
+	AssertEqual(t, s.rateLimit(second).ErrCode, nil)
+	soteErr := s.rateLimit(first)
+	AssertEqual(t, soteErr.ErrCode, 100300)
+	AssertEqual(t, soteErr.ErrorDetails["organization"], "first")
+}
+
+func TestRateLimitAnonymous(t *testing.T) {
+	AddRateLimit(RateLimit{Subject: "test-anonymous", Key: RATELIMITUSER, Rate: 1, Burst: 1})
+	defer RemoveRateLimit("test-anonymous")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		return sError.SoteError{}
+	}
+	// the requestors without a verified token share one bucket whatever their request header
+	AssertEqual(t, s.rateLimit(&Msg{Subject: "test-anonymous", Data: []byte(`{"request-header": {"aws-user-name": "first"}}`)}).ErrCode, nil)
+	soteErr := s.rateLimit(&Msg{Subject: "test-anonymous", Data: []byte(`{"request-header": {"json-web-token": "invalid", "aws-user-name": "second"}}`)})
+	AssertEqual(t, soteErr.ErrCode, 100300)
+	AssertEqual(t, soteErr.ErrorDetails["user"], RATELIMITANONYMOUS)
+	AssertEqual(t, s.rateLimit(rateLimitMsg(t, s, "test-anonymous", "soteuser")).ErrCode, nil)
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	defer SetRateLimits("[]")
+	AssertEqual(t, SetRateLimits(`[{"subject": "bsl.>", "key": "organization", "rate": 50, "burst": 100}]`).FmtErrMsg, "")
+	limits, _ := matchingRateLimits("bsl.fin-trans.trip.add")
+	AssertEqual(t, len(limits), 1)
+	AssertEqual(t, SetRateLimits(`{}`).ErrCode, 207110)
+	AssertEqual(t, SetRateLimits(`[{"subject": "bsl.>", "key": "device", "rate": 50, "burst": 100}]`).ErrCode, 200250)
+	AssertEqual(t, SetRateLimits(`[{"subject": "bsl.>", "key": "user", "burst": 100}]`).FmtErrMsg, "200513: RateLimit.Rate must be populated")
+	limits, _ = matchingRateLimits("bsl.fin-trans.trip.add")
+	AssertEqual(t, len(limits), 1) // the limits are kept when the configuration is invalid
+}
+
+func TestRateLimitKVNoConnection(t *testing.T) {
+	store := KVRateLimits{Run: newDbRun(), Bucket: "ratelimits"}
+	_, _, soteErr := store.Take("user.key", RateLimit{Rate: 1, Burst: 1}, time.Now())
+	AssertEqual(t, soteErr.ErrCode, 209499)
+
+	// the token buckets of the replica are used while the store fails
+	SetRateLimitStore(store)
+	defer SetRateLimitStore(nil)
+	AddRateLimit(RateLimit{Subject: "test-kv", Key: RATELIMITUSER, Rate: 1, Burst: 1})
+	defer RemoveRateLimit("test-kv")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		return sError.SoteError{}
+	}
+	msg := rateLimitMsg(t, s, "test-kv", "soteuser")
+	AssertEqual(t, s.rateLimit(msg).ErrCode, nil)
+	AssertEqual(t, s.rateLimit(msg).ErrCode, 100300)
+}
+
+func TestRateLimitKVConflict(t *testing.T) {
+	conflict, soteErr := pubAckConflict([]byte(`{"stream": "KV_ratelimits", "seq": 2}`))
+	AssertEqual(t, conflict, false)
+	AssertEqual(t, soteErr.ErrCode, nil)
+	conflict, soteErr = pubAckConflict([]byte(`{"error": {"code": 400, "err_code": 10071, "description": "wrong last sequence: 1"}}`))
+	AssertEqual(t, conflict, true)
+	AssertEqual(t, soteErr.ErrCode, nil)
+	// another error with the same description isn't a conflict
+	conflict, soteErr = pubAckConflict([]byte(`{"error": {"code": 503, "err_code": 10077, "description": "wrong last sequence"}}`))
+	AssertEqual(t, conflict, false)
+	AssertEqual(t, soteErr.ErrCode, 210599)
+}
@@ -0,0 +1,105 @@
+package sHelper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	REPLICARETRY = 30 * time.Second // an unhealthy replica is skipped for this long before it is tried again
+)
+
+// replicaHelper is a read replica pool, Select queries are sent to the healthy replicas in turn
+type replicaHelper struct {
+	host       string
+	dbConnInfo sDatabase.ConnInfo
+	query      func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error)
+	retryAt    time.Time
+}
+
+// newReplicas connects to the replica hosts, a replica that can't be reached is left out and the primary is used instead
+func newReplicas(run *Run, hosts []string) (replicas []*replicaHelper) {
+	sLogger.DebugMethod()
+	for _, host := range hosts {
+		dbConnInfo, soteErr := run.GetConnection(sDatabase.DBName, sDatabase.DBUser, sDatabase.DBPassword, host,
+			sDatabase.DBSSLMode, sDatabase.DBPort, 3)
+		if soteErr.ErrCode != nil {
+			sLogger.Info("Database::Replica - " + host + " is not available: " + soteErr.FmtErrMsg)
+			continue
+		}
+		replicas = append(replicas, &replicaHelper{
+			host:       host,
+			dbConnInfo: dbConnInfo,
+			query: func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+				return dbConnInfo.DBPoolPtr.Query(ctx, sql, args...)
+			},
+		})
+	}
+	return
+}
+
+// Primary sends the Select to the primary, e.g. to read the rows just written by the request
+func (q Query) Primary() Query {
+	q.primary = true
+	return q
+}
+
+// readOnly is true when the query can be answered by a replica
+func (q *Query) readOnly() bool {
+	if q.primary || q.action != "SELECT" {
+		return false
+	}
+	for _, sub := range q.with {
+		if sub.query.action != "SELECT" {
+			return false
+		}
+	}
+	return true
+}
+
+// replica returns the next healthy replica or nil when the primary has to be used
+func (dh *DatabaseHelper) replica() *replicaHelper {
+	dh.mu.Lock()
+	defer dh.mu.Unlock()
+	now := time.Now()
+	for range dh.replicas {
+		dh.next = (dh.next + 1) % len(dh.replicas)
+		if r := dh.replicas[dh.next]; now.After(r.retryAt) {
+			return r
+		}
+	}
+	return nil
+}
+
+// unhealthy takes the replica out of the rotation for REPLICARETRY
+func (dh *DatabaseHelper) unhealthy(replica *replicaHelper, err error) {
+	dh.mu.Lock()
+	replica.retryAt = time.Now().Add(REPLICARETRY)
+	dh.mu.Unlock()
+	sLogger.Info("Database::Replica - " + replica.host + " is unhealthy, using the primary: " + err.Error())
+}
+
+// connectionError is true when the statement didn't reach the database, errors reported by postgres are not retried
+func connectionError(err error) bool {
+	var pgErr *pgconn.PgError
+	return err != nil && !errors.As(err, &pgErr) && !timedOut(err)
+}
+
+// route runs the statement on a replica when readOnly is set and falls back to the primary when the replica fails
+func (r *Run) route(ctx context.Context, readOnly bool, sql string, args ...interface{}) (sDatabase.SRows, error) {
+	if readOnly {
+		if replica := r.dbHelper.replica(); replica != nil {
+			tRows, err := replica.query(ctx, sql, args...)
+			if !connectionError(err) {
+				return tRows, err
+			}
+			r.dbHelper.unhealthy(replica, err)
+		}
+	}
+	return r.dbHelper.query(ctx, sql, args...)
+}
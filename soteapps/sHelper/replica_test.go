@@ -0,0 +1,58 @@
+package sHelper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+)
+
+func newReplicaRun(replicaErr error, routed *[]string) *Run {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		*routed = append(*routed, "primary")
+		return nil, nil
+	}
+	run.dbHelper.replicas = []*replicaHelper{{
+		host: "replica1",
+		query: func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+			*routed = append(*routed, "replica1")
+			return nil, replicaErr
+		},
+	}}
+	return run
+}
+
+func TestReplicaRouting(t *testing.T) {
+	var (
+		routed []string
+	)
+	run := newReplicaRun(nil, &routed)
+	Query{Table: "TABLE1"}.Select().Exec(run)
+	Query{Table: "TABLE1"}.Primary().Select().Exec(run)
+	Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{1}}.Insert().Exec(run)
+	Query{Table: "TABLE1", Columns: []string{"COL1"}, Values: []interface{}{1}, Where: "ID=1"}.Update().Exec(run)
+	Query{Table: "TABLE1"}.With("w", Query{Table: "TABLE2", Where: "ID=1"}.Delete("ID")).Select().Exec(run)
+	AssertEqual(t, strings.Join(routed, ","), "replica1,primary,primary,primary,primary")
+}
+
+func TestReplicaFallback(t *testing.T) {
+	var (
+		routed []string
+	)
+	run := newReplicaRun(errors.New("failed to connect to host=replica1"), &routed)
+	_, soteErr := Query{Table: "TABLE1"}.Select().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	Query{Table: "TABLE1"}.Select().Exec(run)
+	AssertEqual(t, strings.Join(routed, ","), "replica1,primary,primary") // the unhealthy replica is skipped
+
+	routed = nil
+	run = newReplicaRun(&pgconn.PgError{Code: "42P01", Message: "relation does not exist"}, &routed)
+	_, soteErr = Query{Table: "TABLE1"}.Select().Exec(run)
+	AssertEqual(t, soteErr.ErrCode, 200999)
+	AssertEqual(t, strings.Join(routed, ","), "replica1")
+}
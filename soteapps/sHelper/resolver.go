@@ -0,0 +1,143 @@
+package sHelper
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// schemaResolver finds the schema of a URL in the embedded files, the local directory, the disk cache or on the network.
+// The URL stays the identity of the schema, the local copies mirror it as <directory>/<host>/<path>.
+type schemaResolver struct {
+	directory string
+	cache     string
+	offline   bool
+	files     map[string]fs.FS
+	get       func(req *http.Request) (*http.Response, error)
+}
+
+var (
+	schemas = newSchemaResolver()
+)
+
+func newSchemaResolver() *schemaResolver {
+	var cache string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cache = filepath.Join(dir, "sote", "schemas")
+	}
+	return &schemaResolver{
+		cache: cache,
+		files: map[string]fs.FS{},
+		get:   http.DefaultClient.Do,
+	}
+}
+
+// SetSchemaDirectory resolves the schema URLs from the local copies in directory before going to the network
+func SetSchemaDirectory(directory string) {
+	schemas.directory = directory
+}
+
+// SetSchemaCache changes the directory of the downloaded schemas, "" turns the cache off
+func SetSchemaCache(directory string) {
+	schemas.cache = directory
+}
+
+// SetSchemaOffline only resolves the schemas from the embedded files, the local directory and the cache
+func SetSchemaOffline(offline bool) {
+	schemas.offline = offline
+}
+
+// AddSchemaFiles resolves the URLs starting with baseURL from files, e.g. an embed.FS with the message schemas
+func AddSchemaFiles(baseURL string, files fs.FS) {
+	schemas.files[strings.TrimSuffix(baseURL, "/")] = files
+}
+
+// read returns the schema of the URL, the fragment (#/definitions/...) is not part of the identity
+func (sr *schemaResolver) read(ref string) (data []byte, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		err error
+	)
+	u, _ := url.Parse(ref)
+	u.Fragment = ""
+	location := u.String()
+	for baseURL, files := range sr.files {
+		if strings.HasPrefix(location, baseURL+"/") {
+			if data, err = fs.ReadFile(files, strings.TrimPrefix(location, baseURL+"/")); err == nil {
+				return
+			}
+		}
+	}
+	if sr.directory != "" {
+		if data, err = ioutil.ReadFile(sr.localPath(sr.directory, u)); err == nil {
+			return
+		}
+	}
+	return sr.download(location, u)
+}
+
+func (sr *schemaResolver) localPath(directory string, u *url.URL) string {
+	return filepath.Join(directory, u.Host, filepath.FromSlash(path.Clean("/"+u.Path)))
+}
+
+// download revalidates the cached copy with its ETag, the cached copy is used when the network is not available
+func (sr *schemaResolver) download(location string, u *url.URL) (data []byte, soteErr sError.SoteError) {
+	var (
+		cached, etag []byte
+		cacheErr     error
+		resp         *http.Response
+	)
+	cacheFile := ""
+	if sr.cache != "" {
+		cacheFile = sr.localPath(sr.cache, u)
+		if cached, cacheErr = ioutil.ReadFile(cacheFile); cacheErr == nil {
+			etag, _ = ioutil.ReadFile(cacheFile + ".etag")
+		}
+	}
+	if sr.offline {
+		if cacheFile == "" || cacheErr != nil {
+			return nil, NewError().FileNotFound(location, "offline mode, the schema is not available locally")
+		}
+		return cached, soteErr
+	}
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err == nil {
+		if len(etag) > 0 && cached != nil {
+			req.Header.Set("If-None-Match", string(etag))
+		}
+		resp, err = sr.get(req)
+	}
+	if err != nil {
+		if cached != nil {
+			sLogger.Info("Schema::Cache - " + location + " is not reachable, using the cached copy: " + err.Error())
+			return cached, soteErr
+		}
+		return nil, NewError().FileNotFound(location, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, soteErr
+	}
+	if data, err = ioutil.ReadAll(resp.Body); err != nil {
+		return nil, NewError().FileNotFound(location, err.Error())
+	}
+	if resp.StatusCode == http.StatusOK && cacheFile != "" {
+		if err = os.MkdirAll(filepath.Dir(cacheFile), 0755); err == nil {
+			if err = ioutil.WriteFile(cacheFile, data, 0644); err == nil {
+				err = ioutil.WriteFile(cacheFile+".etag", []byte(resp.Header.Get("ETag")), 0644)
+			}
+		}
+		if err != nil {
+			sLogger.Info("Schema::Cache - " + location + " couldn't be cached: " + err.Error())
+		}
+	}
+	return
+}
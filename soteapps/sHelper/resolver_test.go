@@ -0,0 +1,96 @@
+package sHelper
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	resolverSchema = `{"properties": {"field1": {"$id": "#/properties/field1", "type": "string"}}}`
+)
+
+func newTestResolver(t *testing.T) (*schemaResolver, string) {
+	dir, err := ioutil.TempDir("", "schemas")
+	AssertEqual(t, err, nil)
+	sr := newSchemaResolver()
+	sr.cache = filepath.Join(dir, "cache")
+	return sr, dir
+}
+
+func TestResolverCache(t *testing.T) {
+	var (
+		requests, notModified int
+	)
+	sr, dir := newTestResolver(t)
+	defer os.RemoveAll(dir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(resolverSchema))
+	}))
+	defer server.Close()
+
+	ref := server.URL + "/messages/schema.json#/definitions/request-header"
+	data, soteErr := sr.read(ref)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, string(data), resolverSchema)
+	data, soteErr = sr.read(ref)
+	AssertEqual(t, string(data), resolverSchema)
+	AssertEqual(t, requests, 2)
+	AssertEqual(t, notModified, 1)
+
+	// GitLab outage
+	sr.get = func(req *http.Request) (*http.Response, error) { return nil, errors.New("connection refused") }
+	data, soteErr = sr.read(ref)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, string(data), resolverSchema)
+
+	sr.offline = true
+	data, _ = sr.read(ref)
+	AssertEqual(t, string(data), resolverSchema)
+	_, soteErr = sr.read(server.URL + "/messages/other.json")
+	AssertEqual(t, soteErr.FmtErrMsg, "209010: "+server.URL+"/messages/other.json file was not found. "+
+		"Message return: offline mode, the schema is not available locally")
+}
+
+func TestResolverLocal(t *testing.T) {
+	sr, dir := newTestResolver(t)
+	defer os.RemoveAll(dir)
+	sr.offline = true
+	sr.directory = filepath.Join(dir, "local")
+	os.MkdirAll(filepath.Join(sr.directory, "gitlab.com", "soteapps"), 0755)
+	ioutil.WriteFile(filepath.Join(sr.directory, "gitlab.com", "soteapps", "local.json"), []byte(resolverSchema), 0644)
+	sr.files["https://gitlab.com/soteapps/messages/-/raw/master"] = fstest.MapFS{
+		"embedded.json": &fstest.MapFile{Data: []byte(resolverSchema)},
+	}
+
+	data, soteErr := sr.read("https://gitlab.com/soteapps/local.json")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, string(data), resolverSchema)
+	data, soteErr = sr.read("https://gitlab.com/soteapps/messages/-/raw/master/embedded.json#/definitions/request-header")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, string(data), resolverSchema)
+}
+
+func TestResolverSchemaValidate(t *testing.T) {
+	AddSchemaFiles("https://gitlab.com/soteapps/test", fstest.MapFS{
+		"schema.json": &fstest.MapFile{Data: []byte(resolverSchema)},
+	})
+	defer delete(schemas.files, "https://gitlab.com/soteapps/test")
+	schema := Schema{
+		FileName:  "https://gitlab.com/soteapps/test/schema.json",
+		StructRef: &TestSchema{},
+	}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+}
@@ -0,0 +1,205 @@
+package sHelper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	REVOCATIONTABLE = "revokedtokens"   // sote.revokedtokens, see db/migration/revokedtokens.sql
+	REVOKESUBJECT   = "bsl.auth.revoke" // subject of RevokeListener
+	KVOPERATION     = "KV-Operation"    // header of the deleted keys of a JetStream KV bucket
+)
+
+type Revocation = sAuthentication.Revocation
+
+type RevokeRequest struct {
+	Header       RequestHeaderSchema `json:"request-header"`
+	TokenId      string              `json:"jti,omitempty"`
+	Username     string              `json:"username,omitempty"`
+	IssuedBefore *time.Time          `json:"issued-before"`
+	ExpiresAt    *time.Time          `json:"expires-at"`
+}
+
+var (
+	revokeSchema     = &Schema{StructRef: &RevokeRequest{}}
+	revokeSchemaOnce sync.Once
+)
+
+// SetRevocationStore rejects the revoked tokens of the store (PostgresRevocations or KVRevocations), the revocation list is
+// reloaded after refresh (default: 1 minute)
+func SetRevocationStore(store sAuthentication.RevocationStore, refresh time.Duration) {
+	sLogger.DebugMethod()
+	sAuthentication.SetRevocationStore(store, refresh)
+}
+
+// RevokeListener revokes the token (jti) or the tokens of the username of the message, the subject must have a policy,
+// e.g. AddPolicy(REVOKESUBJECT, "admin")
+func RevokeListener(s *Subscriber, msg *Msg) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		header RequestHeaderSchema
+		status string
+		body   = RevokeRequest{}
+	)
+	revokeSchemaOnce.Do(func() { revokeSchema.Validate() })
+	if header, soteErr = revokeSchema.ParseAndValidateMsg(s.Run.Env, msg, &body); soteErr.ErrCode == nil {
+		if !sAuthentication.HasPolicy(msg.Subject) {
+			soteErr = sError.GetSError(100100, sError.BuildParams([]string{"[" + strings.Join(msg.Identity.Groups, ", ") + "]", msg.Subject}),
+				sError.EmptyMap)
+		} else {
+			revocation := Revocation{TokenId: body.TokenId, Username: body.Username}
+			if body.IssuedBefore != nil {
+				revocation.IssuedBefore = *body.IssuedBefore
+			}
+			if body.ExpiresAt != nil {
+				revocation.ExpiresAt = *body.ExpiresAt
+			}
+			if soteErr = sAuthentication.Revoke(revocation); soteErr.ErrCode == nil {
+				status = "REVOKED"
+			}
+		}
+	}
+	soteErr = s.PublishMessage(header, soteErr, status)
+	return
+}
+
+// PostgresRevocations keeps the revocation list in the REVOCATIONTABLE table
+type PostgresRevocations struct {
+	Run *Run
+}
+
+func (p PostgresRevocations) Revocations() (list []Revocation, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	query := Query{
+		Table:   REVOCATIONTABLE,
+		Columns: []string{"jti", "username", "issued_before", "expires_at"},
+		Where:   "expires_at IS NULL OR expires_at > now()",
+	}
+	tRows, soteErr := query.Select().Exec(p.Run)
+	if soteErr.ErrCode == nil {
+		for tRows.Next() {
+			var (
+				jti, username           *string
+				issuedBefore, expiresAt *time.Time
+				revocation              Revocation
+			)
+			tRows.Scan(&jti, &username, &issuedBefore, &expiresAt)
+			if jti != nil {
+				revocation.TokenId = *jti
+			}
+			if username != nil {
+				revocation.Username = *username
+			}
+			if issuedBefore != nil {
+				revocation.IssuedBefore = *issuedBefore
+			}
+			if expiresAt != nil {
+				revocation.ExpiresAt = *expiresAt
+			}
+			list = append(list, revocation)
+		}
+		query.Close(tRows, &soteErr)
+	}
+	return
+}
+
+func (p PostgresRevocations) Revoke(revocation Revocation) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	query := Query{
+		Table:   REVOCATIONTABLE,
+		Columns: []string{"revocation_key", "jti", "username", "issued_before", "expires_at"},
+		Values: []interface{}{revocation.Key(), nullString(revocation.TokenId), nullString(revocation.Username),
+			nullTime(revocation.IssuedBefore), nullTime(revocation.ExpiresAt)},
+	}
+	tRows, soteErr := query.Upsert([]string{"revocation_key"}).Exec(p.Run)
+	if soteErr.ErrCode == nil {
+		query.Close(tRows, &soteErr)
+	}
+	return
+}
+
+// KVRevocations keeps the revocation list in the JetStream KV bucket, the deleted keys of the bucket are ignored
+type KVRevocations struct {
+	Run    *Run
+	Bucket string
+}
+
+// CreateBucket adds the stream of the bucket when it doesn't exist
+func (k KVRevocations) CreateBucket() sError.SoteError {
+	sLogger.DebugMethod()
+	return k.bucket().create(0)
+}
+
+func (k KVRevocations) Revocations() (list []Revocation, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		js   nats.JetStreamContext
+		sub  *nats.Subscription
+		info *nats.ConsumerInfo
+		msg  *nats.Msg
+		err  error
+	)
+	if js, soteErr = k.bucket().jetStream(); soteErr.ErrCode != nil {
+		return
+	}
+	if sub, err = js.SubscribeSync(k.bucket().subject(">"), nats.DeliverLastPerSubject(), nats.AckNone()); err != nil {
+		return nil, NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+	}
+	defer sub.Unsubscribe()
+	if info, err = sub.ConsumerInfo(); err == nil && info.NumPending > 0 {
+		for msg, err = sub.NextMsg(KVTIMEOUT); err == nil; msg, err = sub.NextMsg(KVTIMEOUT) {
+			revocation := Revocation{}
+			if msg.Header.Get(KVOPERATION) == "" && json.Unmarshal(msg.Data, &revocation) == nil {
+				list = append(list, revocation)
+			}
+			if metadata, _ := msg.Metadata(); metadata == nil || metadata.NumPending == 0 {
+				break
+			}
+		}
+	}
+	if err != nil {
+		soteErr = NewError(map[string]string{"ERROR": err.Error()}).InternalError()
+	}
+	return
+}
+
+func (k KVRevocations) Revoke(revocation Revocation) sError.SoteError {
+	sLogger.DebugMethod()
+	data, _ := json.Marshal(revocation)
+	return k.bucket().put(kvKey(revocation), data)
+}
+
+func (k KVRevocations) bucket() kvBucket {
+	return kvBucket{run: k.Run, name: k.Bucket}
+}
+
+// kvKey encodes the jti or the username, the keys of a bucket only allow [-/_=.a-zA-Z0-9]
+func kvKey(revocation Revocation) string {
+	if revocation.TokenId != "" {
+		return "jti." + base64.RawURLEncoding.EncodeToString([]byte(revocation.TokenId))
+	}
+	return "username." + base64.RawURLEncoding.EncodeToString([]byte(revocation.Username))
+}
+
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nullTime(value time.Time) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+	return value
+}
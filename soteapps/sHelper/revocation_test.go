@@ -0,0 +1,97 @@
+package sHelper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+func TestRevocationPostgres(t *testing.T) {
+	issuedBefore := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, sql, "SELECT jti, username, issued_before, expires_at FROM sote.revokedtokens WHERE expires_at IS NULL OR expires_at > now()")
+		next := 2
+		return sDatabase.Rows{
+			IEerr: func() error { return nil },
+			INext: func() bool { next--; return next >= 0 },
+			IScan: func(dest ...interface{}) error {
+				if next == 1 {
+					jti := "token-1"
+					*dest[0].(**string) = &jti
+				} else {
+					username := "soteuser"
+					*dest[1].(**string) = &username
+					*dest[2].(**time.Time) = &issuedBefore
+				}
+				return nil
+			},
+		}, nil
+	}
+	list, soteErr := PostgresRevocations{Run: run}.Revocations()
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, len(list), 2)
+	AssertEqual(t, list[0].TokenId, "token-1")
+	AssertEqual(t, list[1].Username, "soteuser")
+	AssertEqual(t, list[1].IssuedBefore, issuedBefore)
+
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		AssertEqual(t, sql, "INSERT INTO sote.revokedtokens (revocation_key, jti, username, issued_before, expires_at) VALUES($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (revocation_key) DO UPDATE SET jti = EXCLUDED.jti, username = EXCLUDED.username, "+
+			"issued_before = EXCLUDED.issued_before, expires_at = EXCLUDED.expires_at")
+		AssertEqual(t, fmt.Sprintf("%v", args), "[username.soteuser <nil> soteuser 2021-06-01 00:00:00 +0000 UTC <nil>]")
+		return sDatabase.Rows{IEerr: func() error { return nil }}, nil
+	}
+	AssertEqual(t, PostgresRevocations{Run: run}.Revoke(Revocation{Username: "soteuser", IssuedBefore: issuedBefore}).FmtErrMsg, "")
+}
+
+func TestRevocationKVNoConnection(t *testing.T) {
+	_, soteErr := KVRevocations{Run: newDbRun(), Bucket: "revocations"}.Revocations()
+	AssertEqual(t, soteErr.ErrCode, 209499)
+	AssertEqual(t, kvKey(Revocation{TokenId: "a/b"}), "jti.YS9i")
+}
+
+func TestRevocationListener(t *testing.T) {
+	var (
+		reply   sError.SoteError
+		revoked []interface{}
+	)
+	s := newSubscriber()
+	createDatabaseHelper(s.Run, &Result{})
+	s.Run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		if len(args) > 0 {
+			revoked = args
+		}
+		return sDatabase.Rows{IEerr: func() error { return nil }, INext: func() bool { return false }}, nil
+	}
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		reply = soteErr
+		return sError.SoteError{}
+	}
+	SetRevocationStore(PostgresRevocations{Run: s.Run}, time.Hour)
+	defer SetRevocationStore(nil, 0)
+	admin := MockToken(t, s.Run.Env, map[string]interface{}{"cognito:groups": []string{"admin"}, "custom:organizations-id": "10003"})
+	msg := &Msg{Subject: REVOKESUBJECT, Data: []byte(`{"request-header": {"aws-user-name": "soteuser", "organizations-id": 10003, "message-id": "1", "role-list": [], "json-web-token": "` + admin + `"},
+		"jti": "token-1"}`)}
+
+	// the listener refuses a subject without policy
+	RevokeListener(s, msg)
+	AssertEqual(t, reply.ErrCode, 100100)
+	AssertEqual(t, revoked == nil, true)
+
+	AddPolicy(REVOKESUBJECT, "admin")
+	defer RemovePolicy(REVOKESUBJECT)
+	AssertEqual(t, RevokeListener(s, msg).FmtErrMsg, "")
+	AssertEqual(t, reply.ErrCode, nil)
+	AssertEqual(t, revoked[0], "jti.token-1")
+
+	// the revoked token is rejected
+	token := MockToken(t, s.Run.Env, map[string]interface{}{"jti": "token-1"})
+	AssertEqual(t, sAuthentication.ValidToken(s.Run.Env.TargetEnvironment, token).ErrCode, 208380)
+}
@@ -20,6 +20,7 @@ type MessageListener func(*Subscriber, *Msg) sError.SoteError
 type Run struct {
 	Env                 Environment
 	Nats                *natsConfig
+	Database            *databaseConfig
 	Subscribers         []*Subscriber
 	ValidateEnvironment func(environment string) sError.SoteError
 	GetNATSURL          func(application, environment string) (string, sError.SoteError)
@@ -40,11 +41,13 @@ type natsConfig struct {
 }
 
 type Msg struct {
-	Subject string
-	Header  nats.Header
-	Data    []byte
-	index   int
-	uuid    string
+	Subject  string
+	Header   nats.Header
+	Data     []byte
+	Identity *Identity // requestor verified by the token, see Schema.ParseAndValidateMsg
+	Schema   *Schema   // schema of the message version, see Subscriber.Negotiate
+	index    int
+	uuid     string
 }
 
 type ReturnChain struct {
@@ -81,6 +84,9 @@ func NewRun(env Environment) *Run {
 			ConnectionName:     "myNATS",
 			CredentialFileName: "",
 		},
+		Database: &databaseConfig{
+			LogLevel: sLogger.InfoLogLevel,
+		},
 	}
 	return &run
 }
@@ -4,11 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"gitlab.com/soteapps/packages/v2021/sAuthentication"
@@ -16,9 +16,11 @@ import (
 	"gitlab.com/soteapps/packages/v2021/sLogger"
 )
 
+// Deprecated: Schema.Version selects the envelope of the request header, see ENVELOPEINLINE and ENVELOPEHEADER
 const SHEMA_VERSION = 1 //temporary released request header inline
 
 type RequestHeaderSchema = sAuthentication.RequestHeaderSchema
+type Identity = sAuthentication.Identity
 
 type FilterHeaderSchema struct {
 	Items    []string               `json:"items"`
@@ -30,33 +32,56 @@ type FilterHeaderSchema struct {
 	Equal    map[string]interface{} `json:"eq"`
 	Greater  map[string]interface{} `json:"gt"`
 	Less     map[string]interface{} `json:"lt"`
+	Cursor   string                 `json:"cursor"`
 }
 
 type Schema struct {
 	FileName       string
 	StructRef      interface{}
+	AllErrors      bool        // Parse reports every violation in the ErrorDetails instead of stopping at the first one
+	ReplyRef       interface{} // struct of the message published in the reply, PublishMessage validates the reply against it
+	ReplyFileName  string      // response schema of the reply, the schema of ReplyRef is used when it isn't set
+	Version        string      // message version, ENVELOPEINLINE for the request header fields inline in the message
+	Deprecated     bool        // the messages of the version are logged and counted, see Subscriber.Negotiate
 	structType     reflect.Type
 	defaultFields  map[string]*jsonProperty
 	enumFields     map[string]*jsonProperty
 	requiredFields map[string]*jsonProperty
 	jsonFields     map[string]*reflect.StructField
 	jsonSchema     jsonSchema
+	document       []byte
+	reply          *Schema
 }
 
 type jsonSchema struct {
-	Required    []string
-	Properties  map[string]*jsonProperty
-	Definitions map[string]*jsonProperty
+	Required             []string
+	Properties           map[string]*jsonProperty
+	Definitions          map[string]*jsonProperty
+	AdditionalProperties interface{}
 }
 
 type jsonProperty struct {
-	Id         string `json:"$id"`
-	Ref        string `json:"$ref"`
-	Default    interface{}
-	Enum       []interface{}
-	Type       string
-	Required   []string
-	Properties map[string]*jsonProperty
+	Id                   string `json:"$id"`
+	Ref                  string `json:"$ref"`
+	Default              interface{}
+	Enum                 []interface{}
+	Type                 string
+	Required             []string
+	Properties           map[string]*jsonProperty
+	MinLength            *int
+	MaxLength            *int
+	Pattern              string
+	Format               string
+	Minimum              *float64
+	Maximum              *float64
+	ExclusiveMinimum     *float64
+	ExclusiveMaximum     *float64
+	MinItems             *int
+	MaxItems             *int
+	Items                *jsonProperty
+	AdditionalProperties interface{} // false or the schema of the properties that are not listed
+	OneOf                []*jsonProperty
+	AnyOf                []*jsonProperty
 }
 
 var (
@@ -127,10 +152,10 @@ func verifyDefinition(s *Schema, propLevel string, def *jsonProperty) {
 
 func loadDefinition(s *Schema, id, name, ref string) *jsonProperty {
 	var (
-		err  error
-		data []byte
-		u    *url.URL
-		resp *http.Response
+		err     error
+		data    []byte
+		u       *url.URL
+		soteErr sError.SoteError
 	)
 	u, _ = url.Parse(ref)
 	if u.Scheme == "file" {
@@ -139,13 +164,8 @@ func loadDefinition(s *Schema, id, name, ref string) *jsonProperty {
 			panic(NewError().FileNotFound(u.Path, absPath).FmtErrMsg)
 		}
 		data, err = ioutil.ReadFile(absPath)
-	} else {
-		resp, err = http.Get(ref)
-		if err != nil {
-			panic(NewError().FileNotFound(ref, err.Error()).FmtErrMsg)
-		}
-		defer resp.Body.Close()
-		data, err = ioutil.ReadAll(resp.Body)
+	} else if data, soteErr = schemas.read(ref); soteErr.ErrCode != nil {
+		panic(soteErr.FmtErrMsg)
 	}
 	if err != nil {
 		panic(err)
@@ -249,22 +269,18 @@ func (s *Schema) Validate() (soteErr sError.SoteError) {
 		err  error
 		data []byte
 		u    *url.URL
-		resp *http.Response
 	)
 	u, _ = url.Parse(s.FileName)
-	if u.Scheme == "" || u.Scheme == "file" {
+	if s.FileName == "" {
+		data, err = json.Marshal(JsonSchema(s.StructRef)) // the service has no published schema
+	} else if u.Scheme == "" || u.Scheme == "file" {
 		absPath, _ := filepath.Abs(s.FileName)
 		if _, err = os.Stat(absPath); err != nil {
 			panic(NewError().FileNotFound(s.FileName, absPath).FmtErrMsg)
 		}
 		data, err = ioutil.ReadFile(absPath)
-	} else {
-		resp, err = http.Get(s.FileName)
-		if err != nil {
-			panic(NewError().FileNotFound(s.FileName, err.Error()).FmtErrMsg)
-		}
-		defer resp.Body.Close()
-		data, err = ioutil.ReadAll(resp.Body)
+	} else if data, soteErr = schemas.read(s.FileName); soteErr.ErrCode != nil {
+		panic(soteErr.FmtErrMsg)
 	}
 	if err != nil {
 		panic(err)
@@ -273,11 +289,35 @@ func (s *Schema) Validate() (soteErr sError.SoteError) {
 	if err != nil {
 		soteErr = NewError().InvalidJson(s.FileName)
 	} else {
+		s.document = data
 		soteErr = s.validateSchema()
 	}
+	if soteErr.ErrCode == nil && s.ReplyFileName != "" && s.ReplyRef == nil {
+		soteErr = NewError(map[string]string{"ERROR": "ReplyRef is required with ReplyFileName"}).InternalError()
+	} else if soteErr.ErrCode == nil && s.ReplyRef != nil && (reflect.TypeOf(s.ReplyRef).Kind() != reflect.Ptr ||
+		reflect.TypeOf(s.ReplyRef).Elem().Kind() != reflect.Struct) {
+		soteErr = NewError().MustBeType("ReplyRef", "pointer to a struct") // ValidateReply creates the reply struct of the pointer
+	} else if soteErr.ErrCode == nil && s.ReplyRef != nil {
+		s.reply = &Schema{FileName: s.ReplyFileName, StructRef: s.ReplyRef, AllErrors: s.AllErrors}
+		soteErr = s.reply.Validate()
+	}
 	return
 }
 
+// ValidateReply checks the message of a reply against the response schema, the reply is valid when there is no response schema
+func (s *Schema) ValidateReply(message interface{}) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	if s.reply == nil {
+		return
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return NewError().InvalidJson(fmt.Sprint(message))
+	}
+	body := reflect.New(s.reply.structType.Elem()).Interface()
+	return s.reply.Parse(data, body)
+}
+
 func (s *Schema) validateSchema() (soteErr sError.SoteError) {
 	missingParameters = []string{}
 	requiredFields = []string{}
@@ -328,13 +368,13 @@ func (s *Schema) Parse(data []byte, body interface{}) (soteErr sError.SoteError)
 			elem := b.Elem()
 
 			// Request Body version 0.1
-			if SHEMA_VERSION == 1 && s.jsonFields["#/properties/request-header"] != nil {
+			if s.envelope() != ENVELOPEHEADER && s.jsonFields["#/properties/request-header"] != nil {
 				e := reflect.ValueOf(body).Elem()
 				h := e.FieldByName("Header")
 				v := h.Interface()
 				if v != nil {
 					header := v.(RequestHeaderSchema)
-					if header.JsonWebToken == "" {
+					if header.JsonWebToken == "" || s.envelope() == ENVELOPEINLINE {
 						json.Unmarshal(data, &header)
 						h.Set(reflect.ValueOf(header))
 					}
@@ -366,11 +406,20 @@ func (s *Schema) Parse(data []byte, body interface{}) (soteErr sError.SoteError)
 					emptyFields = append(emptyFields, id)
 				}
 			}
-			if soteErr.ErrCode == nil && len(emptyFields) > 0 {
-				soteErr = NewError().InvalidParameters(strings.Join(emptyFields, ", "))
+			invalid := &violations{all: s.AllErrors}
+			sort.Strings(emptyFields)
+			if len(emptyFields) > 0 && s.AllErrors {
+				for _, id := range emptyFields {
+					field := id
+					invalid.add(instancePath(field), "required", nil, func(e sErrorHelper) sError.SoteError { return e.InvalidParameters(field) })
+				}
+			} else if len(emptyFields) > 0 {
+				invalid.add(instancePath(emptyFields[0]), "required", nil, func(e sErrorHelper) sError.SoteError {
+					return e.InvalidParameters(strings.Join(emptyFields, ", "))
+				})
 			}
 
-			if soteErr.ErrCode == nil {
+			if !invalid.done() {
 				//validate enum fields
 				for id, prop := range s.enumFields {
 					f := s.jsonFields[id]
@@ -399,11 +448,19 @@ func (s *Schema) Parse(data []byte, body interface{}) (soteErr sError.SoteError)
 						}
 					}
 					if !found {
-						soteErr = NewError().AllowValues(id, v, prop.Enum)
-						break
+						field, enum := id, prop.Enum
+						invalid.add(instancePath(field), fmt.Sprintf("enum %v", enum), v, func(e sErrorHelper) sError.SoteError { return e.AllowValues(field, v, enum) })
+						if invalid.done() {
+							break
+						}
 					}
 				}
 			}
+
+			if !invalid.done() {
+				s.validateKeywords(data, invalid)
+			}
+			soteErr = invalid.error()
 		}
 	}
 	return
@@ -411,8 +468,27 @@ func (s *Schema) Parse(data []byte, body interface{}) (soteErr sError.SoteError)
 
 func (s *Schema) ParseAndValidate(env Environment, data []byte, body interface{}) (rh RequestHeaderSchema, soteErr sError.SoteError) {
 	sLogger.DebugMethod()
+	rh, _, soteErr = s.parseAndValidate(env, data, body)
+	return
+}
+
+// ParseAndValidateMsg is ParseAndValidate attaching the verified identity of the requestor to the message, the request header
+// is rejected when its username or organization isn't the one of the token. The credentials of the NATS headers (json-web-token
+// or the service-token of a trusted service) come before the request header of the message.
+func (s *Schema) ParseAndValidateMsg(env Environment, msg *Msg, body interface{}) (rh RequestHeaderSchema, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	if msg.Schema != nil { // the schema of the message version, body must be its StructRef
+		s = msg.Schema
+	}
+	if soteErr = s.Parse(msg.Data, body); soteErr.ErrCode == nil {
+		rh, msg.Identity, soteErr = s.identify(env, msg)
+	}
+	return
+}
+
+func (s *Schema) parseAndValidate(env Environment, data []byte, body interface{}) (rh RequestHeaderSchema, identity *Identity, soteErr sError.SoteError) {
 	if soteErr = s.Parse(data, body); soteErr.ErrCode == nil {
-		rh, soteErr = sAuthentication.ValidateBody(data, env.TargetEnvironment, env.TestMode)
+		rh, identity, soteErr = sAuthentication.ValidateIdentity(sAuthentication.RequestHeader{Header: s.requestHeader(data)}, env.TargetEnvironment, env.TestMode)
 	}
 	return
 }
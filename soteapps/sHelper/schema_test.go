@@ -3,13 +3,10 @@ package sHelper
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
-	"time"
 
 	"gitlab.com/soteapps/packages/v2021/sError"
 )
@@ -648,10 +645,6 @@ func TestSchemaFunctionalInvalidFile(t *testing.T) {
 }
 
 func TestParseAndValidate(t *testing.T) {
-	fileName := "coverage.out"
-	now := fmt.Sprint(time.Now().Unix())
-	os.Remove(fileName)
-	ioutil.WriteFile(fileName, []byte(now), 0644)
 	schema := Schema{
 		FileName:  "schema_test.json",
 		StructRef: &TestSchema{},
@@ -665,9 +658,9 @@ func TestParseAndValidate(t *testing.T) {
 	}
 	header, soteErr := schema.ParseAndValidate(env, []byte(`{
 		"request-header": {
+			"json-web-token": "`+MockToken(t, env, map[string]interface{}{"custom:organizations-id": "10003"})+`",
 			"aws-user-name": "soteuser",
-			"organizations-id": 10003,
-			"device-id": `+now+`
+			"organizations-id": 10003
 		},
 		"field1": "Hello",
 		"field2": "World"
@@ -678,5 +671,37 @@ func TestParseAndValidate(t *testing.T) {
 	AssertEqual(t, body.Field3, "VALUE1")
 	AssertEqual(t, header.AwsUserName, "soteuser")
 	AssertEqual(t, header.OrganizationId, 10003)
-	AssertEqual(t, fmt.Sprint(header.DeviceId), now)
+}
+
+func TestParseAndValidateMsg(t *testing.T) {
+	schema := Schema{
+		FileName:  "schema_test.json",
+		StructRef: &TestSchema{},
+	}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+	env := Environment{
+		TargetEnvironment: "staging",
+		TestMode:          true,
+	}
+	token := MockToken(t, env, map[string]interface{}{"sub": "user-id", "custom:organizations-id": "10003", "cognito:groups": []string{"admin"}})
+	msg := &Msg{Data: []byte(`{
+		"request-header": {"json-web-token": "` + token + `", "aws-user-name": "soteuser", "organizations-id": 10003},
+		"field1": "Hello",
+		"field2": "World"
+	}`)}
+	_, soteErr := schema.ParseAndValidateMsg(env, msg, &TestSchema{})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, msg.Identity.Subject, "user-id")
+	AssertEqual(t, msg.Identity.OrganizationId, 10003)
+	AssertEqual(t, msg.Identity.Groups[0], "admin")
+
+	// the organization of the request header must be the one of the token
+	msg.Data = []byte(`{
+		"request-header": {"json-web-token": "` + token + `", "aws-user-name": "soteuser", "organizations-id": 10004},
+		"field1": "Hello",
+		"field2": "World"
+	}`)
+	_, soteErr = schema.ParseAndValidateMsg(env, msg, &TestSchema{})
+	AssertEqual(t, soteErr.ErrCode, 208360)
+	AssertEqual(t, msg.Identity == nil, true)
 }
@@ -0,0 +1,159 @@
+package sHelper
+
+import (
+	"crypto"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// SERVICETOKENSEXPIRES is the age of the jti in the KVServiceTokens bucket, longer than the service tokens plus the leeway
+const SERVICETOKENSEXPIRES = 10 * time.Minute
+
+// KVServiceTokens shares the jti of the accepted service tokens in the JetStream KV bucket, a jti is only saved when no
+// replica accepted it before
+type KVServiceTokens struct {
+	Run    *Run
+	Bucket string
+}
+
+var (
+	serviceName   string
+	serviceSigner crypto.Signer
+	serviceMu     sync.RWMutex
+)
+
+// TrustService accepts the requests of the calling service signed with its NKey (the public U... key) or key pair, the
+// roles are checked against the policies (see AddPolicy)
+func TrustService(name string, publicKey interface{}, roles ...string) sError.SoteError {
+	sLogger.DebugMethod()
+	return sAuthentication.TrustService(name, publicKey, roles...)
+}
+
+func DistrustService(name string) {
+	sAuthentication.DistrustService(name)
+}
+
+// SetServiceTokenStore shares the jti of the accepted service tokens between the replicas (e.g. KVServiceTokens), nil keeps
+// them in this replica and a service token is then only accepted once per replica. The jti of this replica are checked
+// while the store fails.
+func SetServiceTokenStore(store sAuthentication.ServiceTokenStore) {
+	sLogger.DebugMethod()
+	sAuthentication.SetServiceTokenStore(store)
+}
+
+// CreateBucket adds the stream of the bucket when it doesn't exist, the jti expire after SERVICETOKENSEXPIRES
+func (k KVServiceTokens) CreateBucket() sError.SoteError {
+	sLogger.DebugMethod()
+	return k.bucket().create(SERVICETOKENSEXPIRES)
+}
+
+func (k KVServiceTokens) Accept(jti string, expiresAt time.Time) (found bool, soteErr sError.SoteError) {
+	value, _ := expiresAt.MarshalText()
+	return k.bucket().update(kvKey(Revocation{TokenId: jti}), value, 0)
+}
+
+func (k KVServiceTokens) bucket() kvBucket {
+	return kvBucket{run: k.Run, name: k.Bucket}
+}
+
+// SetServiceKey signs the requests of the service with the NKey of the NATS credentials file (or seed file) of the service
+func SetServiceKey(name, credentialFileName string) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var (
+		data   []byte
+		signer crypto.Signer
+		err    error
+	)
+	if data, err = ioutil.ReadFile(credentialFileName); err != nil {
+		return NewError().FileNotFound(credentialFileName, err.Error())
+	}
+	if signer, soteErr = sAuthentication.NKeySigner(data); soteErr.ErrCode == nil {
+		SetServiceSigner(name, signer)
+	}
+	return
+}
+
+// SetServiceSigner signs the requests of the service with a key pair (ed25519, ECDSA or RSA private key)
+func SetServiceSigner(name string, signer crypto.Signer) {
+	sLogger.DebugMethod()
+	serviceMu.Lock()
+	defer serviceMu.Unlock()
+	serviceName, serviceSigner = name, signer
+}
+
+// ServiceHeader returns the NATS headers of a request of the service on the subject, the receiver must trust the service.
+// onBehalfOf is the request header of the user the service acts for, the receiver only accepts its aws-user-name and
+// organizations-id.
+func ServiceHeader(subject string, onBehalfOf ...RequestHeaderSchema) (header nats.Header, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	var token string
+	serviceMu.RLock()
+	defer serviceMu.RUnlock()
+	if serviceSigner == nil {
+		return nil, NewError().MustBePopulated("service key")
+	}
+	if token, soteErr = sAuthentication.ServiceToken(serviceName, subject, serviceSigner, onBehalfOf...); soteErr.ErrCode == nil {
+		header = nats.Header{}
+		header.Set(sAuthentication.SERVICETOKENHEADER, token)
+	}
+	return
+}
+
+// msgHeader reads the request header of the message, the credentials of the NATS headers come before the message
+func (s *Schema) msgHeader(msg *Msg) (header RequestHeaderSchema) {
+	header = s.requestHeader(msg.Data)
+	if msg.Header.Get("json-web-token") != "" {
+		natsHeader := sAuthentication.HeaderRequest(msg.Header)
+		header.JsonWebToken = natsHeader.JsonWebToken
+		if natsHeader.AwsUserName != "" {
+			header.AwsUserName = natsHeader.AwsUserName
+		}
+		if natsHeader.OrganizationId != 0 {
+			header.OrganizationId = natsHeader.OrganizationId
+		}
+		if natsHeader.MessageId != "" {
+			header.MessageId = natsHeader.MessageId
+		}
+	}
+	return
+}
+
+// identify verifies the service token of the NATS headers or the user token of the request header, the token is only
+// validated once per message (see verify). The request header must be the user the token was signed for.
+func (s *Schema) identify(env Environment, msg *Msg) (rh RequestHeaderSchema, identity *Identity, soteErr sError.SoteError) {
+	rh = s.msgHeader(msg)
+	if msg.Header.Get(sAuthentication.SERVICETOKENHEADER) != "" {
+		if identity, soteErr = s.verify(env, msg, rh); soteErr.ErrCode == nil {
+			if soteErr = identity.Match(rh); soteErr.ErrCode != nil {
+				identity = nil
+			}
+		}
+	} else {
+		rh, identity, soteErr = sAuthentication.MatchIdentity(sAuthentication.RequestHeader{Header: rh}, msg.Identity, env.TargetEnvironment,
+			env.TestMode)
+	}
+	return
+}
+
+// verify returns the identity of the service token or the user token of the message, msg.Identity keeps the identity of
+// the verified token for the policies, the rate limits and the listener
+func (s *Schema) verify(env Environment, msg *Msg, rh RequestHeaderSchema) (identity *Identity, soteErr sError.SoteError) {
+	if msg.Identity != nil {
+		return msg.Identity, soteErr
+	}
+	if token := msg.Header.Get(sAuthentication.SERVICETOKENHEADER); token != "" {
+		identity, soteErr = sAuthentication.ValidateServiceToken(token, msg.Subject)
+	} else {
+		identity, soteErr = sAuthentication.TokenIdentity(rh.JsonWebToken, env.TargetEnvironment, env.TestMode)
+	}
+	if soteErr.ErrCode == nil {
+		msg.Identity = identity
+	}
+	return
+}
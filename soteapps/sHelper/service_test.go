@@ -0,0 +1,92 @@
+package sHelper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+func TestServiceHeader(t *testing.T) {
+	keyPair, _ := nkeys.CreateUser()
+	seed, _ := keyPair.Seed()
+	publicKey, _ := keyPair.PublicKey()
+	seedFile, _ := ioutil.TempFile("", "service.nk")
+	defer os.Remove(seedFile.Name())
+	seedFile.Write(seed)
+	seedFile.Close()
+	AssertEqual(t, SetServiceKey("billing", seedFile.Name()).FmtErrMsg, "")
+	defer SetServiceSigner("", nil)
+	AssertEqual(t, TrustService("billing", publicKey, "service").FmtErrMsg, "")
+	defer DistrustService("billing")
+
+	header, soteErr := ServiceHeader("test-subject")
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	schema := Schema{FileName: "schema_test.json", StructRef: &TestSchema{}}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+	msg := &Msg{Subject: "test-subject", Header: header, Data: []byte(`{"request-header": {}, "field1": "Hello", "field2": "World"}`)}
+	env := Environment{TargetEnvironment: "staging", TestMode: true}
+	_, soteErr = schema.ParseAndValidateMsg(env, msg, &TestSchema{})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, msg.Identity.Service, "billing")
+
+	// the policies apply to the roles of the service
+	AddPolicy("test-subject", "service")
+	defer RemovePolicy("test-subject")
+	s := newSubscriber()
+	s.PublishMessage = func(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
+		return sError.SoteError{}
+	}
+	AssertEqual(t, s.authorize(msg).ErrCode, nil)
+	other := &Msg{Subject: "test-subject.other", Header: header, Data: msg.Data}
+	AddPolicy("test-subject.other", "admin")
+	defer RemovePolicy("test-subject.other")
+	AssertEqual(t, s.authorize(other).ErrCode, 208360) // the token is for test-subject
+
+	// the request header must be the user the service acts for
+	header, _ = ServiceHeader("test-subject", RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003})
+	data := `{"request-header": {"aws-user-name": "%v", "organizations-id": 10003}, "field1": "Hello", "field2": "World"}`
+	_, soteErr = schema.ParseAndValidateMsg(env, &Msg{Subject: "test-subject", Header: header, Data: []byte(fmt.Sprintf(data, "other"))},
+		&TestSchema{})
+	AssertEqual(t, soteErr.ErrCode, 208360)
+	header, _ = ServiceHeader("test-subject", RequestHeaderSchema{AwsUserName: "soteuser", OrganizationId: 10003})
+	msg = &Msg{Subject: "test-subject", Header: header, Data: []byte(fmt.Sprintf(data, "soteuser"))}
+	_, soteErr = schema.ParseAndValidateMsg(env, msg, &TestSchema{})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, msg.Identity.Username, "soteuser")
+	// a service token is only accepted once
+	_, soteErr = schema.ParseAndValidateMsg(env, &Msg{Subject: "test-subject", Header: header, Data: msg.Data}, &TestSchema{})
+	AssertEqual(t, soteErr.ErrCode, 208360)
+}
+
+func TestServiceHeaderToken(t *testing.T) {
+	env := Environment{TargetEnvironment: "staging", TestMode: true}
+	schema := Schema{FileName: "schema_test.json", StructRef: &TestSchema{}}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+	// the token of the NATS headers comes before the request header of the message
+	msg := &Msg{
+		Header: nats.Header{"json-web-token": []string{MockToken(t, env, map[string]interface{}{"custom:organizations-id": "10003"})}},
+		Data: []byte(`{"request-header": {"json-web-token": "invalid", "aws-user-name": "soteuser", "organizations-id": 10003},
+			"field1": "Hello", "field2": "World"}`),
+	}
+	rh, soteErr := schema.ParseAndValidateMsg(env, msg, &TestSchema{})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, rh.OrganizationId, 10003)
+	AssertEqual(t, msg.Identity.Username, "soteuser")
+
+	_, soteErr = ServiceHeader("test-subject")
+	AssertEqual(t, soteErr.ErrCode != nil, true)
+}
+
+func TestServiceTokenKVNoConnection(t *testing.T) {
+	store := KVServiceTokens{Run: newDbRun(), Bucket: "servicetokens"}
+	found, soteErr := store.Accept("trips-1", time.Now().Add(time.Minute))
+	AssertEqual(t, found, false)
+	AssertEqual(t, soteErr.ErrCode, 209499)
+	AssertEqual(t, store.CreateBucket().ErrCode, 209499)
+}
@@ -0,0 +1,146 @@
+package sHelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+// databaseConfig controls the timeouts and the logging of the statements sent by Query, Batch and CopyFrom
+type databaseConfig struct {
+	Timeout   time.Duration // default statement timeout, Query.Timeout overrides it (0 = no timeout)
+	LogLevel  string        // sLogger.InfoLogLevel, sLogger.DebugLogLevel or "" to not log the statements
+	LogValues bool          // the bound values are redacted in the log unless this is set
+	SlowQuery time.Duration // statements running at least this long are logged at INFO with duration and row count (0 = off)
+	Explain   bool          // log the EXPLAIN plan of every statement when the log level is DEBUG
+}
+
+// statementRows cancels the context of the statement and reports a slow query when the rows are closed
+type statementRows struct {
+	sDatabase.SRows
+	config *databaseConfig
+	sql    string
+	start  time.Time
+	cancel context.CancelFunc
+	closed bool
+}
+
+func (s *statementRows) Close() {
+	s.SRows.Close()
+	if !s.closed {
+		s.closed = true
+		s.cancel()
+		if msg := s.config.slow(s.sql, time.Since(s.start), s.CommandTag); msg != "" {
+			sLogger.Info(msg)
+		}
+	}
+}
+
+func (r *Run) databaseConfig() *databaseConfig {
+	if r.Database == nil {
+		r.Database = &databaseConfig{LogLevel: sLogger.InfoLogLevel}
+	}
+	return r.Database
+}
+
+func (r *Run) context(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := r.dbHelper.dbConnInfo.DBContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// statement sends the sql to the database, the context is released when the returned rows are closed
+func (r *Run) statement(label string, timeout time.Duration, readOnly bool, sql string, args ...interface{}) (sDatabase.SRows, error) {
+	config := r.databaseConfig()
+	config.log(label, sql, args)
+	r.explain(sql, args)
+	ctx, cancel := r.context(timeout)
+	tRows, err := r.route(ctx, readOnly, sql, args...)
+	if err != nil || tRows == nil {
+		cancel()
+		return tRows, err
+	}
+	return &statementRows{SRows: tRows, config: config, sql: sql, start: time.Now(), cancel: cancel}, nil
+}
+
+// explain logs the plan of the sql at DEBUG, the statement itself is not executed
+func (r *Run) explain(sql string, args []interface{}) {
+	if !r.databaseConfig().Explain || sLogger.GetLogLevel() != sLogger.DebugLogLevel {
+		return
+	}
+	var (
+		plan []string
+		line string
+	)
+	ctx, cancel := r.context(r.databaseConfig().Timeout)
+	defer cancel()
+	tRows, err := r.dbHelper.query(ctx, "EXPLAIN "+sql, args...)
+	if err == nil {
+		for tRows.Next() {
+			if err = tRows.Scan(&line); err == nil {
+				plan = append(plan, line)
+			}
+		}
+		tRows.Close()
+		err = tRows.Err()
+	}
+	if err != nil {
+		sLogger.Debug("Database::Explain - " + err.Error())
+	} else {
+		sLogger.Debug("Database::Explain - " + sql + "\n" + strings.Join(plan, "\n"))
+	}
+}
+
+func (c *databaseConfig) log(label, sql string, args []interface{}) {
+	msg := c.message(label, sql, args)
+	switch c.LogLevel {
+	case sLogger.InfoLogLevel:
+		sLogger.Info(msg)
+	case sLogger.DebugLogLevel:
+		sLogger.Debug(msg)
+	}
+}
+
+func (c *databaseConfig) message(label, sql string, args []interface{}) string {
+	msg := "Database::" + label + " - " + sql
+	if len(args) == 0 {
+		return msg
+	} else if c.LogValues {
+		return msg + fmt.Sprintf(" Values: %v", args)
+	}
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = fmt.Sprintf("$%v=<%T>", i+1, arg) // only the type of the value is logged
+	}
+	return msg + " Values: [" + strings.Join(redacted, " ") + "]"
+}
+
+// slow returns the log message of a slow statement or "" when the statement was fast enough
+func (c *databaseConfig) slow(sql string, duration time.Duration, commandTag func() pgconn.CommandTag) string {
+	if c.SlowQuery <= 0 || duration < c.SlowQuery {
+		return ""
+	}
+	return fmt.Sprintf("Database::SlowQuery - %v Duration: %v Rows: %v", sql, duration, commandTag().RowsAffected())
+}
+
+func (q *Query) timeout(r *Run) time.Duration {
+	if q.Timeout > 0 {
+		return q.Timeout
+	}
+	return r.databaseConfig().Timeout
+}
+
+func timedOut(err error) bool {
+	return pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,76 @@
+package sHelper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/soteapps/packages/v2021/sDatabase"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+func TestStatementTimeout(t *testing.T) {
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	_, soteErr := Query{Table: "TABLE1", Timeout: time.Millisecond}.Select().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "101010: Query (sote.TABLE1) timed out")
+
+	run.Database.Timeout = time.Millisecond
+	_, soteErr = Query{Table: "TABLE1"}.Select().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "101010: Query (sote.TABLE1) timed out")
+}
+
+func TestStatementLogValues(t *testing.T) {
+	config := &databaseConfig{LogLevel: sLogger.InfoLogLevel}
+	values := []interface{}{"secret", 10}
+	AssertEqual(t, config.message("Exec", "SELECT $1, $2", values), "Database::Exec - SELECT $1, $2 Values: [$1=<string> $2=<int>]")
+	AssertEqual(t, config.message("Exec", "SELECT 1", nil), "Database::Exec - SELECT 1")
+	config.LogValues = true
+	AssertEqual(t, config.message("Exec", "SELECT $1, $2", values), "Database::Exec - SELECT $1, $2 Values: [secret 10]")
+}
+
+func TestStatementSlowQuery(t *testing.T) {
+	config := &databaseConfig{}
+	commandTag := func() pgconn.CommandTag { return pgconn.CommandTag("SELECT 5") }
+	AssertEqual(t, config.slow("SELECT 1", time.Second, commandTag), "")
+	config.SlowQuery = time.Second
+	AssertEqual(t, config.slow("SELECT 1", time.Millisecond, commandTag), "")
+	AssertEqual(t, config.slow("SELECT 1", 2*time.Second, commandTag), "Database::SlowQuery - SELECT 1 Duration: 2s Rows: 5")
+}
+
+func TestStatementExplain(t *testing.T) {
+	var (
+		statements []string
+	)
+	sLogger.SetLogLevelDebug()
+	defer sLogger.SetLogLevelInfo()
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	run.Database.Explain = true
+	run.dbHelper.query = func(ctx context.Context, sql string, args ...interface{}) (sDatabase.SRows, error) {
+		statements = append(statements, sql)
+		next := true
+		return sDatabase.Rows{
+			INext: func() bool {
+				next = !next
+				return !next
+			},
+			IScan: func(dest ...interface{}) error {
+				*dest[0].(*string) = "Seq Scan on table1"
+				return nil
+			},
+			IEerr: func() error { return nil },
+		}, nil
+	}
+	tRows, soteErr := Query{Table: "TABLE1"}.Select().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	tRows.Close()
+	AssertEqual(t, len(statements), 2)
+	AssertEqual(t, statements[0], "EXPLAIN SELECT * FROM sote.TABLE1")
+	AssertEqual(t, statements[1], "SELECT * FROM sote.TABLE1")
+}
@@ -17,6 +17,7 @@ type Subscriber struct {
 	ConsumerName string
 	Subject      string
 	Schema       *Schema
+	Schemas      map[string]*Schema // other versions of the message schema, see AddSchema and Negotiate
 	Listener     MessageListener
 
 	PullSubscribe   func() sError.SoteError
@@ -111,6 +112,7 @@ func (s *Subscriber) publish(message interface{}, subject ...string) sError.Sote
 
 func (s *Subscriber) publishMessage(header RequestHeaderSchema, soteErr sError.SoteError, message interface{}) sError.SoteError {
 	sLogger.DebugMethod()
+	var replyErr sError.SoteError
 	m := map[string]interface{}{
 		"message-id": header.MessageId,
 	}
@@ -118,10 +120,23 @@ func (s *Subscriber) publishMessage(header RequestHeaderSchema, soteErr sError.S
 		m["error"] = soteErr
 	} else {
 		m["message"] = message
+		if s.Schema != nil {
+			if replyErr = s.Schema.ValidateReply(message); replyErr.ErrCode != nil && s.Run.Env.TestMode {
+				// fail fast, the requestor gets the violation instead of the reply that doesn't match the response schema
+				delete(m, "message")
+				m["error"] = replyErr
+			} else if replyErr.ErrCode != nil {
+				sLogger.Info(fmt.Sprintf("Reply of %v doesn't match the response schema: %v", s.Subject, replyErr.FmtErrMsg))
+				replyErr = sError.SoteError{}
+			}
+		}
 	}
 	data, err := json.MarshalIndent(m, "", "\t")
 	if err != nil {
 		return NewError().InvalidJson(fmt.Sprint(message))
 	}
-	return s.Publish(string(data), fmt.Sprintf("%v.%v", header.OrganizationId, header.AwsUserName))
+	if publishErr := s.Publish(string(data), fmt.Sprintf("%v.%v", header.OrganizationId, header.AwsUserName)); replyErr.ErrCode == nil {
+		return publishErr
+	}
+	return replyErr
 }
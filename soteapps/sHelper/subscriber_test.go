@@ -123,6 +123,64 @@ func TestSubscribePublishMessageParseError(t *testing.T) {
 	AssertEqual(t, strings.Contains(soteErr.FmtErrMsg, "couldn't be parsed - Invalid JSON error"), true)
 }
 
+func newReplySubscriber(t *testing.T) *Subscriber {
+	type TestRequest struct {
+		Field1 string `json:"field1"`
+	}
+	type TestReply struct {
+		Id string `json:"id"`
+	}
+	s := newSubscriber()
+	s.Schema = &Schema{StructRef: &TestRequest{}, ReplyRef: &TestReply{}}
+	AssertEqual(t, s.Schema.Validate().FmtErrMsg, "")
+	return s
+}
+
+func TestSubscribePublishMessageReply(t *testing.T) {
+	var published int
+	s := newReplySubscriber(t)
+	s.Run.Env.TestMode = false
+	s.Publish = func(message interface{}, subject ...string) sError.SoteError {
+		published++
+		return sError.SoteError{}
+	}
+	header := RequestHeaderSchema{OrganizationId: 1000, AwsUserName: "soteuser", MessageId: "123"}
+	AssertEqual(t, s.PublishMessage(header, sError.SoteError{}, map[string]string{"id": "1"}).FmtErrMsg, "")
+	// production only logs the violation
+	AssertEqual(t, s.PublishMessage(header, sError.SoteError{}, map[string]string{"name": "1"}).FmtErrMsg, "")
+	// errors are not validated
+	AssertEqual(t, s.PublishMessage(header, NewError().InternalError(), nil).FmtErrMsg, "")
+	AssertEqual(t, published, 3)
+}
+
+func TestSubscribePublishMessageReplyTestMode(t *testing.T) {
+	var published string
+	s := newReplySubscriber(t)
+	s.Run.Env.TestMode = true
+	s.Publish = func(message interface{}, subject ...string) sError.SoteError {
+		published = message.(string)
+		return sError.SoteError{}
+	}
+	soteErr := s.PublishMessage(RequestHeaderSchema{MessageId: "123"}, sError.SoteError{}, map[string]string{"name": "1"})
+	AssertEqual(t, soteErr.FmtErrMsg, "206200: Message doesn't match signature. Sender must provide the following parameter names: #/properties/id")
+	// the requestor gets the violation instead of the reply
+	AssertEqual(t, strings.Contains(published, `"ErrCode": 206200`), true)
+	AssertEqual(t, strings.Contains(published, `"message"`), false)
+}
+
+func TestSubscribeReplyFileName(t *testing.T) {
+	schema := Schema{StructRef: &TestSchema{}, ReplyFileName: "reply.json"}
+	AssertEqual(t, schema.Validate().ErrCode, 210599)
+}
+
+func TestSubscribeReplyRefNotPointer(t *testing.T) {
+	type TestReply struct {
+		Id string `json:"id"`
+	}
+	schema := Schema{StructRef: &TestSchema{}, ReplyRef: TestReply{}}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "200200: ReplyRef must be of type pointer to a struct")
+}
+
 func TestSubscribeConsumerError(t *testing.T) {
 	defer func() {
 		recover()
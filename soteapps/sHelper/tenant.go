@@ -0,0 +1,133 @@
+package sHelper
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const TENANTCOLUMN = "organizations_id"
+
+var (
+	tenantTables = map[string]string{} // schema.table -> column
+)
+
+// AddTenantTable declares a table (sote schema when it is not qualified) whose rows belong to an organization.
+// Queries on the table must be scoped with Query.TenantScope or explicitly opt-out with Query.AllTenants.
+func AddTenantTable(table string, column ...string) {
+	sLogger.DebugMethod()
+	if len(column) == 1 {
+		tenantTables[qualifiedTable(table)] = column[0]
+	} else {
+		tenantTables[qualifiedTable(table)] = TENANTCOLUMN
+	}
+}
+
+func RemoveTenantTable(table string) {
+	delete(tenantTables, qualifiedTable(table))
+}
+
+func qualifiedTable(table string) string {
+	if !strings.Contains(table, ".") {
+		return "sote." + table
+	}
+	return table
+}
+
+// TenantScope restricts the query to the rows of the organization of the request header.
+// It must be called before Select, Insert, Update or Delete.
+func (q Query) TenantScope(header RequestHeaderSchema) Query {
+	organizationId := header.OrganizationId
+	q.tenant = &organizationId
+	q.allTenants = false
+	q.header = &header
+	return q
+}
+
+// AllTenants disables the tenant scope for administrative operations on all organizations.
+func (q Query) AllTenants() Query {
+	q.tenant = nil
+	q.allTenants = true
+	return q
+}
+
+func (q *Query) tenantColumn() (string, bool) {
+	return q.tenantOf(getTable(q))
+}
+
+func (q *Query) tenantOf(table string) (string, bool) {
+	column, ok := tenantTables[table]
+	if !ok {
+		return "", false
+	} else if q.allTenants {
+		sLogger.Info(fmt.Sprintf("Database::%v - tenant scope of %v is disabled", q.action, table))
+		return "", false
+	} else if q.tenant == nil {
+		q.soteErr = NewError().MustBePopulated(fmt.Sprintf("Tenant scope (%v)", table))
+		return "", false
+	}
+	return column, true
+}
+
+func (q *Query) scopeWhere() {
+	alias := ""
+	if q.action == "SELECT" && q.Alias != "" {
+		alias = q.Alias + "."
+	}
+	if column, ok := q.tenantColumn(); ok {
+		q.Values = append(q.Values, *q.tenant)
+		q.andWhere(fmt.Sprintf("%v%v = $%v", alias, column, len(q.Values)))
+	}
+	if softDeleteTables[getTable(q)] && !q.withDeleted {
+		q.andWhere(alias + DELETEDATCOLUMN + " IS NULL")
+	}
+}
+
+func (q *Query) andWhere(condition string) {
+	if q.Where != "" {
+		q.Where = fmt.Sprintf("(%v) AND ", q.Where)
+	}
+	q.Where += condition
+}
+
+func (q *Query) scopeInsert() {
+	column, ok := q.tenantColumn()
+	if !ok {
+		return
+	} else if len(q.Columns) == 0 {
+		q.soteErr = NewError().MustBePopulated("Columns")
+		return
+	}
+	index := len(q.Columns)
+	for i, name := range q.Columns {
+		if name == column {
+			index = i
+		}
+	}
+	if index == len(q.Columns) {
+		q.Columns = append(append([]string{}, q.Columns...), column)
+	}
+	q.Values = scopeValues(q.Values, index, *q.tenant)
+	if len(q.Rows) > 0 {
+		rows := make([][]interface{}, len(q.Rows))
+		for i, row := range q.Rows {
+			rows[i] = scopeValues(row, index, *q.tenant)
+		}
+		q.Rows = rows
+	}
+}
+
+// scopeValues returns a copy of values with the organization at index (appended at the end)
+func scopeValues(values []interface{}, index int, organizationId int) []interface{} {
+	if len(values) == 0 {
+		return values
+	}
+	scoped := append([]interface{}{}, values...)
+	if index < len(scoped) {
+		scoped[index] = organizationId
+	} else {
+		scoped = append(scoped, organizationId)
+	}
+	return scoped
+}
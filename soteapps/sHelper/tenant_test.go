@@ -0,0 +1,60 @@
+package sHelper
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTenantScope(t *testing.T) {
+	AddTenantTable("TENANT")
+	defer RemoveTenantTable("TENANT")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+	header := RequestHeaderSchema{OrganizationId: 10000}
+
+	query := Query{Table: "TENANT", Where: "ID=1"}.TenantScope(header).Select()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "SELECT * FROM sote.TENANT WHERE (ID=1) AND organizations_id = $1")
+	AssertEqual(t, fmt.Sprint(query.Values), "[10000]")
+
+	query = Query{Table: "TENANT", Columns: []string{"COL1"}, Values: []interface{}{"Hello"}}.TenantScope(header).Update()
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "UPDATE sote.TENANT SET COL1 = $1 WHERE organizations_id = $2")
+
+	query = Query{Table: "TENANT", Where: "ID=1"}.TenantScope(header).Delete("ID")
+	query.Exec(run)
+	AssertEqual(t, query.Sql.String(), "DELETE FROM sote.TENANT WHERE (ID=1) AND organizations_id = $1 RETURNING ID")
+}
+
+func TestTenantScopeInsert(t *testing.T) {
+	AddTenantTable("myschema.TENANT", "org_id")
+	defer RemoveTenantTable("myschema.TENANT")
+	header := RequestHeaderSchema{OrganizationId: 10000}
+	values := []interface{}{"Hello", 1}
+
+	query := Query{Table: "TENANT", Schema: "myschema", Columns: []string{"COL1", "org_id"}, Values: values}.TenantScope(header).Insert()
+	AssertEqual(t, query.Sql.String(), "INSERT INTO myschema.TENANT (COL1, org_id) VALUES($1, $2)")
+	AssertEqual(t, fmt.Sprint(query.Values), "[Hello 10000]")
+	AssertEqual(t, fmt.Sprint(values), "[Hello 1]")
+
+	query = Query{Table: "TENANT", Schema: "myschema", Columns: []string{"ID", "COL1"}, Rows: [][]interface{}{{1, "Hello"}, {2, "World"}}}.
+		TenantScope(header).Upsert([]string{"ID"})
+	AssertEqual(t, query.Sql.String(), "INSERT INTO myschema.TENANT (ID, COL1, org_id) VALUES($1, $2, $3), ($4, $5, $6) "+
+		"ON CONFLICT (ID) DO UPDATE SET COL1 = EXCLUDED.COL1, org_id = EXCLUDED.org_id WHERE TENANT.org_id = EXCLUDED.org_id")
+	AssertEqual(t, fmt.Sprint(query.Values), "[1 Hello 10000 2 World 10000]")
+}
+
+func TestTenantScopeMissing(t *testing.T) {
+	AddTenantTable("TENANT")
+	defer RemoveTenantTable("TENANT")
+	run := newDbRun()
+	createDatabaseHelper(run, &Result{})
+
+	_, soteErr := Query{Table: "TENANT", Where: "ID=1"}.Delete().Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "200513: Tenant scope (sote.TENANT) must be populated")
+
+	query := Query{Table: "TENANT", Where: "ID=1"}.AllTenants().Delete()
+	_, soteErr = query.Exec(run)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, query.Sql.String(), "DELETE FROM sote.TENANT WHERE ID=1")
+}
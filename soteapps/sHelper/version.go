@@ -0,0 +1,129 @@
+package sHelper
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sError"
+	"gitlab.com/soteapps/packages/v2021/sLogger"
+)
+
+const (
+	VERSIONHEADER  = "Message-Version" // NATS header of the message version
+	VERSIONFIELD   = "message-version" // field of the message, used when the NATS header isn't set
+	ENVELOPEINLINE = "0.1"             // the request header fields are inline in the message
+	ENVELOPEHEADER = "1.0"             // the request header is the request-header object of the message
+)
+
+var (
+	// deprecated messages received per subject and version, published on /debug/vars
+	deprecatedMessages = expvar.NewMap("deprecatedMessages")
+)
+
+// envelope returns the version of the message envelope, schemas without a version accept both envelopes
+func (s *Schema) envelope() string {
+	if s.Version == ENVELOPEINLINE || s.Version == "" {
+		return s.Version
+	}
+	return ENVELOPEHEADER
+}
+
+// requestHeader reads the request header of the message according to the envelope of the schema
+func (s *Schema) requestHeader(data []byte) (header RequestHeaderSchema) {
+	rh := sAuthentication.RequestHeader{}
+	switch s.envelope() {
+	case "":
+		if json.Unmarshal(data, &rh); rh.Header.AwsUserName == "" {
+			header = rh.RequestHeaderSchema
+		} else {
+			header = rh.Header
+		}
+	case ENVELOPEINLINE:
+		json.Unmarshal(data, &header)
+	case ENVELOPEHEADER:
+		json.Unmarshal(data, &rh)
+		header = rh.Header
+	}
+	return
+}
+
+// AddSchema registers another version of the message schema, Schema is used for the messages without a version
+func (s *Subscriber) AddSchema(schema *Schema) (soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	if schema.Version == "" {
+		return NewError().MustBePopulated("Schema.Version")
+	}
+	if schema.structType == nil {
+		if soteErr = schema.Validate(); soteErr.ErrCode != nil {
+			return
+		}
+	}
+	if s.Schemas == nil {
+		s.Schemas = map[string]*Schema{}
+	}
+	s.Schemas[schema.Version] = schema
+	return
+}
+
+// Negotiate returns the schema of the message version and sets msg.Schema, the version is read from the NATS header or from
+// the message
+func (s *Subscriber) Negotiate(msg *Msg) (schema *Schema, soteErr sError.SoteError) {
+	sLogger.DebugMethod()
+	version := msg.Header.Get(VERSIONHEADER)
+	if version == "" {
+		var envelope map[string]interface{}
+		json.Unmarshal(msg.Data, &envelope)
+		if v, ok := envelope[VERSIONFIELD]; ok && v != nil {
+			version = fmt.Sprint(v)
+		}
+	}
+	if version == "" || (s.Schema != nil && s.Schema.Version == version) {
+		schema = s.Schema
+	} else if schema = s.Schemas[version]; schema == nil {
+		versions := []string{}
+		for v := range s.Schemas {
+			versions = append(versions, v)
+		}
+		if s.Schema != nil && s.Schema.Version != "" {
+			versions = append(versions, s.Schema.Version)
+		}
+		sort.Strings(versions)
+		return nil, NewError().AllowValues(VERSIONFIELD, version, versions)
+	}
+	if schema == nil {
+		soteErr = NewError(map[string]string{"ERROR": "The subscriber has no schema"}).InternalError()
+	} else if schema.Deprecated {
+		deprecatedMessages.Add(fmt.Sprintf("%v@%v", s.Subject, schema.Version), 1)
+		sLogger.Info(fmt.Sprintf("Deprecated message version %v of %v", schema.Version, s.Subject))
+	}
+	if soteErr.ErrCode == nil {
+		msg.Schema = schema
+	}
+	return
+}
+
+// negotiate selects the schema of the message version before the listener is called, the requestor of an unknown version
+// gets the error in the reply
+func (s *Subscriber) negotiate(msg *Msg) (soteErr sError.SoteError) {
+	if s.Schema == nil && len(s.Schemas) == 0 {
+		return
+	}
+	if _, soteErr = s.Negotiate(msg); soteErr.ErrCode != nil {
+		s.PublishMessage(s.msgSchema(msg).msgHeader(msg), soteErr, nil)
+	}
+	return
+}
+
+// msgSchema returns the schema of the message version, the schema of the subscriber before the negotiation
+func (s *Subscriber) msgSchema(msg *Msg) *Schema {
+	switch {
+	case msg.Schema != nil:
+		return msg.Schema
+	case s.Schema != nil:
+		return s.Schema
+	}
+	return &Schema{}
+}
@@ -0,0 +1,90 @@
+package sHelper
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+type TestSchemaV1 struct {
+	Header RequestHeaderSchema `json:"request-header"`
+	Field1 string              `json:"field1"`
+}
+
+type TestSchemaV2 struct {
+	Header RequestHeaderSchema `json:"request-header"`
+	Field2 string              `json:"field2"`
+}
+
+func newVersionSubscriber(t *testing.T) *Subscriber {
+	s := newSubscriber()
+	s.Schema = &Schema{StructRef: &TestSchemaV2{}, Version: "2.0"}
+	AssertEqual(t, s.Schema.Validate().FmtErrMsg, "")
+	AssertEqual(t, s.AddSchema(&Schema{StructRef: &TestSchemaV1{}, Version: "1.0", Deprecated: true}).FmtErrMsg, "")
+	return s
+}
+
+func TestNegotiate(t *testing.T) {
+	s := newVersionSubscriber(t)
+	schema, soteErr := s.Negotiate(&Msg{Data: []byte(`{"field2": "value"}`)})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, schema.Version, "2.0")
+
+	schema, _ = s.Negotiate(&Msg{Data: []byte(`{"message-version": "1.0", "field1": "value"}`)})
+	AssertEqual(t, schema.Version, "1.0")
+	AssertEqual(t, deprecatedMessages.Get("test-subject@1.0").String(), "1")
+
+	schema, _ = s.Negotiate(&Msg{Header: nats.Header{VERSIONHEADER: []string{"2.0"}}, Data: []byte(`{"message-version": "1.0"}`)})
+	AssertEqual(t, schema.Version, "2.0")
+
+	_, soteErr = s.Negotiate(&Msg{Data: []byte(`{"message-version": 3}`)})
+	AssertEqual(t, soteErr.FmtErrMsg, "200250: message-version (3) must contain one of these values: [1.0 2.0]")
+}
+
+func TestParseAndValidateMsgVersion(t *testing.T) {
+	s := newVersionSubscriber(t)
+	env, _ := NewEnvironment(ENVDEFAULTAPPNAME, ENVDEFAULTTARGET, ENVDEFAULTTARGET)
+	msg := &Msg{Header: nats.Header{VERSIONHEADER: []string{"1.0"}}, Data: []byte(`{"field1": "Hello", "request-header": {"json-web-token": "` +
+		MockToken(t, env, map[string]interface{}{"custom:organizations-id": "10003"}) + `", "aws-user-name": "soteuser", "organizations-id": 10003, "message-id": "1", "role-list": []}}`)}
+	_, soteErr := s.Negotiate(msg)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	// the message is parsed with the schema of its version
+	body := TestSchemaV1{}
+	_, soteErr = s.Schema.ParseAndValidateMsg(env, msg, &body)
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, body.Field1, "Hello")
+}
+
+func TestAddSchemaVersion(t *testing.T) {
+	s := newSubscriber()
+	AssertEqual(t, s.AddSchema(&Schema{StructRef: &TestSchema{}}).ErrCode, 200513)
+}
+
+func TestSchemaEnvelope(t *testing.T) {
+	type TestSchema struct {
+		Header RequestHeaderSchema `json:"request-header"`
+	}
+	data := []byte(`{"aws-user-name": "inline", "request-header": {"aws-user-name": "header", "json-web-token": "token", "message-id": "1", "role-list": []}}`)
+	for version, user := range map[string]string{"": "header", ENVELOPEINLINE: "inline", ENVELOPEHEADER: "header", "2.0": "header"} {
+		schema := Schema{StructRef: &TestSchema{}, Version: version}
+		AssertEqual(t, schema.Validate().FmtErrMsg, "")
+		body := TestSchema{}
+		AssertEqual(t, schema.Parse(data, &body).FmtErrMsg, "")
+		AssertEqual(t, body.Header.AwsUserName, user)
+	}
+	AssertEqual(t, (&Schema{Version: ENVELOPEINLINE}).requestHeader(data).AwsUserName, "inline")
+	AssertEqual(t, (&Schema{Version: "2.0"}).requestHeader(data).AwsUserName, "header")
+}
+
+func TestParseAndValidateEnvelope(t *testing.T) {
+	type TestSchema struct {
+		Header RequestHeaderSchema `json:"request-header"`
+	}
+	schema := Schema{StructRef: &TestSchema{}, Version: ENVELOPEHEADER}
+	AssertEqual(t, schema.Validate().FmtErrMsg, "")
+	env, _ := NewEnvironment(ENVDEFAULTAPPNAME, ENVDEFAULTTARGET, ENVDEFAULTTARGET)
+	rh, soteErr := schema.ParseAndValidate(env, []byte(`{"request-header": {"aws-user-name": "soteuser", "organizations-id": 10003, "message-id": "1", "json-web-token": "`+
+		MockToken(t, env, map[string]interface{}{"custom:organizations-id": "10003"})+`", "role-list": []}}`), &TestSchema{})
+	AssertEqual(t, soteErr.FmtErrMsg, "")
+	AssertEqual(t, rh.AwsUserName, "soteuser")
+}
@@ -0,0 +1,48 @@
+// Package sTesting holds the helpers of the unit tests, only the test files may import it since the tokens it signs are
+// trusted by the services of the target environment.
+package sTesting
+
+import (
+	"sync"
+
+	"gitlab.com/soteapps/packages/v2021/sAuthentication"
+	"gitlab.com/soteapps/packages/v2021/sError"
+)
+
+type iTesting interface {
+	Helper()
+	Fatal(args ...interface{})
+}
+
+var (
+	issuerMu sync.Mutex
+	issuer   *sAuthentication.LocalIssuer
+)
+
+// Token returns a token signed by a local issuer trusted in the target environment, the claims replace the default
+// claims of a Cognito access token (e.g. cognito:groups)
+func Token(t iTesting, tEnvironment string, claims ...map[string]interface{}) string {
+	t.Helper()
+	var (
+		token   string
+		soteErr sError.SoteError
+	)
+	issuerMu.Lock()
+	defer issuerMu.Unlock()
+	if issuer == nil {
+		if issuer, soteErr = sAuthentication.NewLocalIssuer(""); soteErr.ErrCode != nil {
+			issuer = nil
+			t.Fatal(soteErr.FmtErrMsg)
+		}
+	}
+	sAuthentication.UseLocalIssuer(tEnvironment, issuer)
+	if len(claims) == 1 {
+		token, soteErr = issuer.Token(claims[0])
+	} else {
+		token, soteErr = issuer.Token(nil)
+	}
+	if soteErr.ErrCode != nil {
+		t.Fatal(soteErr.FmtErrMsg)
+	}
+	return token
+}
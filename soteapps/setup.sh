@@ -4,8 +4,8 @@ trap "kill 0" EXIT
 export ORGID=10000
 export USERID=soteuser
 export COGNITOID=5d5147e2-57fc-48a6-b493-1783931ae9c0
-export DEVICE_ID=`date +%s`
-
-echo $DEVICE_ID > .git/device.info
+# the services in test mode only trust the tokens of the local issuer (.git/local-issuer.pem) with SOTE_LOCAL_ISSUER=true
+export SOTE_LOCAL_ISSUER=true
+export TOKEN=`go run gitlab.com/soteapps/packages/v2021/cmd/testtoken -c '{"username": "'$USERID'", "custom:organizations-id": "'$ORGID'", "cognito:groups": ["CLIENT_ADMIN", "EXECUTIVE"]}'`
 
 nats sub $ORGID.$USERID &
\ No newline at end of file
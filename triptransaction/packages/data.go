@@ -16,7 +16,7 @@ func createTripFinancialTransactions(s *sHelper.Subscriber, body FintransAdd) (i
 		Values: []interface{}{body.Header.AwsUserName, body.Header.OrganizationId, body.ClientCompanyId, body.TripId,
 			body.FintransType, body.Currency, body.Amount, body.CostIsUnexpected, body.LoadName, body.Memo},
 	}
-	tRows, soteErr := query.Insert("tripfinancialtransactions_id").Exec(s.Run)
+	tRows, soteErr := query.TenantScope(body.Header).Insert("tripfinancialtransactions_id").Exec(s.Run)
 	if soteErr.ErrCode == nil {
 		for tRows.Next() {
 			tRows.Scan(&id)
@@ -33,7 +33,7 @@ func removeTripFinancialTransactions(s *sHelper.Subscriber, body FintransRemove,
 		Table: "tripfinancialtransactions",
 		Where: whereClause,
 	}
-	tRows, soteErr := query.Delete("tripfinancialtransactions_id").Exec(s.Run)
+	tRows, soteErr := query.TenantScope(body.Header).Delete("tripfinancialtransactions_id").Exec(s.Run)
 	if soteErr.ErrCode == nil {
 		for tRows.Next() {
 			tRows.Scan(&id)
@@ -48,7 +48,7 @@ func listTripFinancialTransactions(s *sHelper.Subscriber, body FintransList) (sH
 	query := sHelper.Query{
 		Table:  "tripfinancialtransactions",
 		Filter: &body.Filter,
-	}.Pagination()
+	}.TenantScope(body.Header).Pagination()
 	if len(body.Filter.Items) == 0 {
 		query.Filter.Items = []string{"tripfinancialtransactions_id", "organizations_id", "client_company_id", "trips_id", "financialtransactions_type",
 			"currency_type", "transactions_amount", "cost_is_unexpected", "load_name", "memo", "transactions_timestamp", "created_by_requestor_username"} // display default columns
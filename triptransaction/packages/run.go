@@ -51,6 +51,9 @@ var (
 func Run(env sHelper.Environment) (soteErr sError.SoteError) {
 	sLogger.DebugMethod()
 	helper := sHelper.NewHelper(env)
+	sHelper.AddTenantTable("tripfinancialtransactions")
+	sHelper.AddSoftDeleteTable("tripfinancialtransactions")
+	sHelper.AddAuditTable("tripfinancialtransactions", "tripfinancialtransactions_id")
 	soteErr = addSchema.Validate()
 	if soteErr.ErrCode == nil {
 		soteErr = removeSchema.Validate()
@@ -88,7 +91,7 @@ func addFintrans(s *sHelper.Subscriber, message *sHelper.Msg) (soteErr sError.So
 		id     int64
 	)
 	body := FintransAdd{}
-	header, soteErr = addSchema.ParseAndValidate(s.Run.Env, message.Data, &body)
+	header, soteErr = addSchema.ParseAndValidateMsg(s.Run.Env, message, &body)
 	if soteErr.ErrCode == nil {
 		id, soteErr = createTripFinancialTransactions(s, body)
 		soteErr = s.PublishMessage(header, soteErr, map[string]int64{
@@ -108,7 +111,7 @@ func removeFintrans(s *sHelper.Subscriber, message *sHelper.Msg) (soteErr sError
 		status string
 	)
 	body := FintransRemove{}
-	header, soteErr = removeSchema.ParseAndValidate(s.Run.Env, message.Data, &body)
+	header, soteErr = removeSchema.ParseAndValidateMsg(s.Run.Env, message, &body)
 	if soteErr.ErrCode == nil {
 		whereClause := fmt.Sprintf("tripfinancialtransactions_id=%v", body.Id)
 		id, soteErr = removeTripFinancialTransactions(s, body, whereClause)
@@ -131,7 +134,7 @@ func listFintrans(s *sHelper.Subscriber, message *sHelper.Msg) (soteErr sError.S
 		result sHelper.QueryResult
 	)
 	body := FintransList{}
-	header, soteErr = listSchema.ParseAndValidate(s.Run.Env, message.Data, &body)
+	header, soteErr = listSchema.ParseAndValidateMsg(s.Run.Env, message, &body)
 	if soteErr.ErrCode == nil {
 		result, soteErr = listTripFinancialTransactions(s, body)
 	}
@@ -8,6 +8,7 @@ import (
 
 	"gitlab.com/soteapps/packages/v2021/sError"
 	"gitlab.com/soteapps/packages/v2021/sHelper"
+	"gitlab.com/soteapps/packages/v2021/sTesting"
 )
 
 var (
@@ -37,6 +38,10 @@ func createSubscriber(t *testing.T, schema sHelper.Schema) sHelper.Subscriber {
 	return s
 }
 
+func mockToken(t *testing.T) string {
+	return sTesting.Token(t, "staging", map[string]interface{}{"custom:organizations-id": "10003"})
+}
+
 func TestRun(t *testing.T) {
 	os.Chdir("..")
 	env := sHelper.MockRunHelper(t, natsConsumerName, natsSubject)
@@ -84,7 +89,7 @@ func TestRunAddFintrans(t *testing.T) {
 	})
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -116,7 +121,7 @@ func TestRunInvalidCustomBody(t *testing.T) {
 	}
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -148,7 +153,7 @@ func TestRunRemoveFintrans(t *testing.T) {
 	})
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -183,7 +188,7 @@ func TestRunRemoveFintransNotFoundError(t *testing.T) {
 	}
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -210,7 +215,7 @@ func TestRunListFintrans(t *testing.T) {
 	})
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -240,7 +245,7 @@ func TestRunListFintransError(t *testing.T) {
 	})
 	data, err := json.Marshal(map[string]interface{}{
 		"request-header": map[string]interface{}{
-			"json-web-token":   "eyJraWQiOvxxxxxxx",
+			"json-web-token":   mockToken(t),
 			"message-id":       "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
 			"aws-user-name":    "soteuser",
 			"organizations-id": 10003,
@@ -4,11 +4,10 @@ source <(curl -s https://gitlab.com/soteapps/packages/-/raw/v2021/setup.sh)
 echo `cat <<EOF
 {
     "request-header": {
-        "json-web-token": "eyJraWQiOvxxxxxxx",
+        "json-web-token": "$TOKEN",
         "message-id": "1a8eb33e-9db2-11eb-a8b3-0242ac130003",
         "aws-user-name": "$USERID",
         "organizations-id": $ORGID,
-        "device-id": $DEVICE_ID,
         "role-list": [
             "CLIENT_ADMIN",
             "EXECUTIVE"